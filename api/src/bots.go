@@ -0,0 +1,288 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+)
+
+type BotDifficulty string
+
+const (
+	// Human players aren't bots
+	NoBot BotDifficulty = ""
+
+	// Easy bots make a random legal move
+	EasyBot BotDifficulty = "easy"
+
+	// Normal bots shed their action cards first, keep their wilds for last, choose the color they hold
+	// the most of for wilds, and jump in whenever they can
+	NormalBot BotDifficulty = "normal"
+
+	// Hard bots also keep to the color they hold the most of, attack the next player when they're close
+	// to going out, catch players who forget to call UNO and challenge suspicious wild+4s
+	HardBot BotDifficulty = "hard"
+)
+
+// Bots can only keep playing among themselves for so long before handing back to the humans
+const maxBotMoves = 2000
+
+// BotMove is a move a bot made, and the game as it stood after the move
+type BotMove struct {
+	Action Action
+	Game   *Game
+}
+
+// parseBotDifficulty returns the difficulty named by a setting, defaulting to normal
+func parseBotDifficulty(value string) (BotDifficulty, error) {
+	switch BotDifficulty(value) {
+	case NoBot:
+		return NormalBot, nil
+
+	case EasyBot, NormalBot, HardBot:
+		return BotDifficulty(value), nil
+	}
+
+	return NoBot, &GameError{message: fmt.Sprintf("Unknown bot difficulty: %s", value)}
+}
+
+// AddBot returns a game with a computer controlled player added. Bots can only be added in the lobby.
+func AddBot(game *Game, difficulty BotDifficulty) (*Game, error) {
+	if game.State != GameCreated {
+		return game, &GameError{message: "Bots can only be added before the game starts"}
+	}
+
+	// Bots are named after the first free seat number
+	name := ""
+	for i := 1; name == "" || GetPlayerIndex(game, name) != -1; i++ {
+		name = fmt.Sprintf("Bot %d", i)
+	}
+
+	game = AddPlayer(game, name)
+	game.Players[len(game.Players)-1].Bot = difficulty
+
+	return game, nil
+}
+
+// RunBots returns the game after every bot has made the moves it's waiting to make, along with each of
+// the moves made
+func RunBots(game *Game) (*Game, []BotMove) {
+	moves := []BotMove{}
+
+	for len(moves) < maxBotMoves {
+		moved := false
+
+		for i, player := range game.Players {
+			if player.Bot == NoBot {
+				continue
+			}
+
+			action, ok := BotAction(game, i)
+			if !ok {
+				continue
+			}
+
+			next, _, err := Apply(game, action)
+			if err != nil {
+				log.Printf("Bot %s made an invalid move: %s", player.Name, err)
+				continue
+			}
+
+			game = next
+			moves = append(moves, BotMove{Action: action, Game: game})
+			moved = true
+
+			break
+		}
+
+		if !moved {
+			break
+		}
+	}
+
+	return game, moves
+}
+
+// gatherEvents returns a copy of the game whose Events are the events given, followed by those of every
+// move made after them, so everything that happened in one update reaches the players. The games
+// recorded with the moves are left untouched.
+func gatherEvents(game *Game, events []Event, moves []BotMove) *Game {
+	gathered := cloneGame(game)
+	gathered.Events = append([]Event{}, events...)
+
+	for _, move := range moves {
+		gathered.Events = append(gathered.Events, move.Game.Events...)
+	}
+
+	return gathered
+}
+
+// BotAction returns the move the bot at playerIndex wants to make, if it has one to make. The bot only
+// looks at what the player could see, and only makes moves the engine accepts.
+func BotAction(game *Game, playerIndex int) (Action, bool) {
+	player := game.Players[playerIndex]
+	moves := GetLegalMoves(game, playerIndex)
+	rng := botRandom(game)
+
+	action := Action{Player: playerIndex}
+
+	switch {
+	case len(moves.SwapTargets) > 0:
+		action.Kind = SwapHandsAction
+		action.Target = botSwapTarget(game, player.Bot, moves.SwapTargets, rng)
+
+	case game.PendingChoice.Kind == StartingColorChoice && len(moves.WildColors) > 0:
+		action.Kind = ChooseColorAction
+		action.Color = botColor(game, player.Bot, player.Cards, -1, rng)
+
+	case moves.CanCallUno && !player.CalledUno:
+		action.Kind = CallUnoAction
+
+	case player.Bot == HardBot && len(moves.CatchablePlayers) > 0:
+		action.Kind = CatchUnoAction
+		action.Target = moves.CatchablePlayers[0]
+
+	case player.Bot != EasyBot && len(moves.JumpInCards) > 0:
+		action.Kind = JumpInAction
+		action.Cards = moves.JumpInCards[:1]
+		action.DiscardPileCount = len(game.DiscardPile)
+
+	case moves.CanChallenge && botChallenges(game, player.Bot, rng):
+		action.Kind = ChallengeAction
+
+	case len(moves.PlayableCards) > 0 && !(player.Bot == EasyBot && moves.CanDraw && rng.Intn(len(moves.PlayableCards)+1) == 0):
+		cardIndex := botCard(game, player.Bot, playerIndex, moves.PlayableCards, rng)
+
+		action.Kind = PlayAction
+		action.Cards = []int{cardIndex}
+
+		if player.Cards[cardIndex].IsWild() {
+			action.Color = botColor(game, player.Bot, player.Cards, cardIndex, rng)
+		}
+
+	case moves.CanDraw:
+		action.Kind = DrawAction
+
+	case moves.CanDoneDrawing:
+		action.Kind = PassAction
+
+	default:
+		return action, false
+	}
+
+	return action, true
+}
+
+// botCard returns the index of the card the bot plays, out of the cards it can play
+func botCard(game *Game, difficulty BotDifficulty, playerIndex int, playable []int, rng *rand.Rand) int {
+	if difficulty == EasyBot {
+		return playable[rng.Intn(len(playable))]
+	}
+
+	cards := game.Players[playerIndex].Cards
+	held := colorCounts(cards, -1)
+	threatened := len(game.Players[nextSeat(game, playerIndex)].Cards) <= 2
+
+	best := playable[0]
+	bestScore := -1
+
+	for _, cardIndex := range playable {
+		if score := cardScore(cards[cardIndex], difficulty, held, threatened); score > bestScore {
+			best = cardIndex
+			bestScore = score
+		}
+	}
+
+	return best
+}
+
+// cardScore returns how keen a bot is to play a card. held is how many cards of each color are in the
+// bot's hand, and threatened is set when the next player is close to going out.
+func cardScore(card Card, difficulty BotDifficulty, held map[Color]int, threatened bool) int {
+	score := 0
+
+	switch {
+	case card.Kind == WildDrawFour:
+		score = 1
+
+	case card.Kind == Wild:
+		score = 0
+
+	case card.Kind == Number:
+		score = 10 + card.Value
+
+	default:
+		score = 30
+	}
+
+	if difficulty == HardBot {
+		// Stay on the color with the most cards left to follow it, and hold back the attacking cards
+		// until the next player is about to go out
+		if !card.IsWild() {
+			score += 5 * held[card.Color]
+		}
+
+		if threatened && card.Kind != Number && card.Kind != Wild {
+			score += 100
+		}
+	}
+
+	return score
+}
+
+// botColor returns the color the bot chooses for a wild, ignoring the wild being played
+func botColor(game *Game, difficulty BotDifficulty, cards []Card, playedIndex int, rng *rand.Rand) Color {
+	if difficulty == EasyBot {
+		return colors[rng.Intn(len(colors))]
+	}
+
+	held := colorCounts(cards, playedIndex)
+
+	best := colors[rng.Intn(len(colors))]
+	for _, color := range colors {
+		if held[color] > held[best] {
+			best = color
+		}
+	}
+
+	return best
+}
+
+// colorCounts returns how many cards of each color are in a hand, leaving out the card at skipIndex
+func colorCounts(cards []Card, skipIndex int) map[Color]int {
+	counts := map[Color]int{}
+
+	for i, card := range cards {
+		if i != skipIndex && !card.IsWild() {
+			counts[card.Color]++
+		}
+	}
+
+	return counts
+}
+
+// botSwapTarget returns the player the bot swaps hands with under Seven-O
+func botSwapTarget(game *Game, difficulty BotDifficulty, targets []int, rng *rand.Rand) int {
+	if difficulty == EasyBot {
+		return targets[rng.Intn(len(targets))]
+	}
+
+	best := targets[0]
+	for _, target := range targets {
+		if len(game.Players[target].Cards) < len(game.Players[best].Cards) {
+			best = target
+		}
+	}
+
+	return best
+}
+
+// botChallenges returns true if the bot challenges the wild+4 played on it. Only hard bots challenge,
+// and only when the player had plenty of cards to choose from.
+func botChallenges(game *Game, difficulty BotDifficulty, rng *rand.Rand) bool {
+	if difficulty != HardBot || game.WildDrawFour == nil {
+		return false
+	}
+
+	return len(game.Players[game.WildDrawFour.Player].Cards) >= 5 && rng.Intn(2) == 0
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// Color is the color of a card, or of the color chosen for a wild
+type Color string
+
+const (
+	NoColor Color = ""
+	Red     Color = "R"
+	Green   Color = "G"
+	Blue    Color = "B"
+	Yellow  Color = "Y"
+)
+
+// Kind is what sort of card a card is
+type Kind int
+
+const (
+	Number Kind = iota
+	Skip
+	Reverse
+	DrawTwo
+	Wild
+	WildDrawFour
+)
+
+// The names used for each kind of card in the card notation. Number cards are written as their value.
+var kindNames = map[Kind]string{
+	Skip:         "skip",
+	Reverse:      "rev",
+	DrawTwo:      "+2",
+	Wild:         "wild",
+	WildDrawFour: "wild+4",
+}
+
+// Card is a single uno card. Wilds have no color, and only number cards have a value.
+//
+// Cards are written as the color followed by the value or action, e.g. "R5", "Gskip", "Brev" and
+// "Y+2", or "wild" and "wild+4" for the wild cards. This is the format cards are stored and sent in.
+// The zero Card is no card at all, and is written as an empty string.
+type Card struct {
+	Color Color
+	Kind  Kind
+	Value int
+}
+
+type CardError struct {
+	card string
+}
+
+func (e *CardError) Error() string {
+	return "Not a card: " + e.card
+}
+
+// NumberCard returns the number card with the given color and value
+func NumberCard(color Color, value int) Card {
+	return Card{Color: color, Kind: Number, Value: value}
+}
+
+// ActionCard returns the skip, reverse or +2 card with the given color
+func ActionCard(color Color, kind Kind) Card {
+	return Card{Color: color, Kind: kind}
+}
+
+// WildCard returns a wild or wild+4 card
+func WildCard(kind Kind) Card {
+	return Card{Kind: kind}
+}
+
+func parseColor(color string) (Color, bool) {
+	for _, validColor := range colors {
+		if Color(color) == validColor {
+			return validColor, true
+		}
+	}
+
+	return NoColor, false
+}
+
+// ParseCard parses a card written in the card notation, e.g. "R5" or "wild+4"
+func ParseCard(card string) (Card, error) {
+	switch card {
+	case kindNames[Wild]:
+		return WildCard(Wild), nil
+
+	case kindNames[WildDrawFour]:
+		return WildCard(WildDrawFour), nil
+	}
+
+	if len(card) < 2 {
+		return Card{}, &CardError{card: card}
+	}
+
+	color, ok := parseColor(card[0:1])
+	if !ok {
+		return Card{}, &CardError{card: card}
+	}
+
+	symbol := card[1:]
+
+	for _, kind := range []Kind{Skip, Reverse, DrawTwo} {
+		if symbol == kindNames[kind] {
+			return ActionCard(color, kind), nil
+		}
+	}
+
+	value, err := strconv.Atoi(symbol)
+	if err != nil || len(symbol) != 1 {
+		return Card{}, &CardError{card: card}
+	}
+
+	return NumberCard(color, value), nil
+}
+
+// ParseCards parses a list of cards written in the card notation
+func ParseCards(cards []string) ([]Card, error) {
+	parsed := []Card{}
+
+	for _, card := range cards {
+		c, err := ParseCard(card)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed = append(parsed, c)
+	}
+
+	return parsed, nil
+}
+
+// String returns the card written in the card notation
+func (c Card) String() string {
+	if c == (Card{}) {
+		return ""
+	}
+
+	if c.IsWild() {
+		return kindNames[c.Kind]
+	}
+
+	if c.Kind == Number {
+		return string(c.Color) + strconv.Itoa(c.Value)
+	}
+
+	return string(c.Color) + kindNames[c.Kind]
+}
+
+func (c Card) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
+
+func (c *Card) UnmarshalJSON(data []byte) error {
+	var card string
+	if err := json.Unmarshal(data, &card); err != nil {
+		return err
+	}
+
+	if card == "" {
+		*c = Card{}
+		return nil
+	}
+
+	parsed, err := ParseCard(card)
+	if err != nil {
+		return err
+	}
+
+	*c = parsed
+	return nil
+}
+
+// IsWild returns true for wild and wild+4 cards
+func (c Card) IsWild() bool {
+	return c.Kind == Wild || c.Kind == WildDrawFour
+}
+
+// IsNumber returns true if the card is a number card with the given value
+func (c Card) IsNumber(value int) bool {
+	return c.Kind == Number && c.Value == value
+}
+
+// SameSymbol returns true if two colored cards show the same number or action, regardless of color
+func (c Card) SameSymbol(other Card) bool {
+	if c.IsWild() || other.IsWild() {
+		return false
+	}
+
+	return c.Kind == other.Kind && c.Value == other.Value
+}
+
+// DrawPenalty returns the number of cards the next player must draw when the card is played
+func (c Card) DrawPenalty() int {
+	switch c.Kind {
+	case DrawTwo:
+		return 2
+
+	case WildDrawFour:
+		return 4
+	}
+
+	return 0
+}
+
+// Points returns the number of points the card is worth when it's left in a player's hand at the end
+// of a round
+func (c Card) Points() int {
+	switch c.Kind {
+	case Number:
+		return c.Value
+
+	case Wild, WildDrawFour:
+		return 50
+	}
+
+	// Skips, reverses and +2s
+	return 20
+}
@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math/rand"
 	"strconv"
+	"strings"
 
 	"github.com/go-redis/redis/v8"
 	"gopkg.in/olahol/melody.v1"
@@ -29,6 +31,7 @@ type GameStatus struct {
 	GameId        string      `json:"gameId"`
 	GamePneumonic string      `json:"gamePneumonic"`
 	IsHost        bool        `json:"isHost"`
+	Spectating    bool        `json:"spectating"`
 	Game          PlayersGame `json:"game"`
 	Abandoned     bool        `json:"abandoned"`
 }
@@ -39,6 +42,12 @@ type GameResponse struct {
 	Game  GameStatus `json:"d"`
 }
 
+type ReplayResponse struct {
+	ReqId  string `json:"reqId"`
+	Verb   string `json:"v"`
+	Replay Replay `json:"d"`
+}
+
 type GameUpdate struct {
 	Verb string     `json:"v"`
 	Game GameStatus `json:"d"`
@@ -75,35 +84,45 @@ func sendResponse(session *melody.Session, response Response) {
 	session.Write(text)
 }
 
-func SendGameUpdate(session *melody.Session, gameId string, game *Game, abandoned bool) {
-	persistentSession, _ := GetPersistentSession(session)
+// isHost returns true if the session's player is hosting the game
+func isHost(persistentSession *PersistentSession, game *Game) bool {
+	return !persistentSession.Spectating && persistentSession.PlayerName != "" && game.Host == persistentSession.PlayerName
+}
 
+// gameStatus returns the game as the session sees it. Players see their own hand, spectators see only
+// what's on the table, and anyone else sees the game as abandoned.
+func gameStatus(persistentSession *PersistentSession, gameId string, game *Game, abandoned bool) GameStatus {
 	playerIndex := GetPlayerIndex(game, persistentSession.PlayerName)
 
-	// Abandoned status
-	response := GameUpdate{
-		Verb: "gameState",
-		Game: GameStatus{
+	if abandoned || (playerIndex == -1 && !persistentSession.Spectating) {
+		return GameStatus{
 			GameId:        gameId,
 			GamePneumonic: "",
-			IsHost:        persistentSession.GameHost,
+			IsHost:        isHost(persistentSession, game),
 			Abandoned:     true,
-		},
+		}
 	}
 
-	if !(playerIndex == -1 || abandoned) {
-		playersGame := GetPlayersGame(game, playerIndex)
+	if persistentSession.Spectating {
+		playerIndex = -1
+	}
 
-		response = GameUpdate{
-			Verb: "gameState",
-			Game: GameStatus{
-				GameId:        gameId,
-				GamePneumonic: game.GamePneumonic,
-				IsHost:        persistentSession.GameHost,
-				Game:          *playersGame,
-				Abandoned:     abandoned,
-			},
-		}
+	return GameStatus{
+		GameId:        gameId,
+		GamePneumonic: game.GamePneumonic,
+		IsHost:        isHost(persistentSession, game),
+		Spectating:    persistentSession.Spectating,
+		Game:          *GetPlayersGame(game, playerIndex),
+		Abandoned:     abandoned,
+	}
+}
+
+func SendGameUpdate(session *melody.Session, gameId string, game *Game, abandoned bool) {
+	persistentSession, _ := GetPersistentSession(session)
+
+	response := GameUpdate{
+		Verb: "gameState",
+		Game: gameStatus(persistentSession, gameId, game, abandoned),
 	}
 
 	text, _ := json.Marshal(response)
@@ -116,29 +135,7 @@ func SendGameResponse(session *melody.Session, cmd *Command, gameId string, game
 	response := GameResponse{
 		ReqId: cmd.ReqId,
 		Verb:  cmd.Verb,
-		Game: GameStatus{
-			GameId:        gameId,
-			GamePneumonic: "",
-			IsHost:        persistentSession.GameHost,
-			Abandoned:     true,
-		},
-	}
-
-	playerIndex := GetPlayerIndex(game, persistentSession.PlayerName)
-
-	if !(playerIndex == -1 || abandoned) {
-		playersGame := GetPlayersGame(game, playerIndex)
-		response = GameResponse{
-			ReqId: cmd.ReqId,
-			Verb:  cmd.Verb,
-			Game: GameStatus{
-				GameId:        gameId,
-				GamePneumonic: game.GamePneumonic,
-				IsHost:        persistentSession.GameHost,
-				Game:          *playersGame,
-				Abandoned:     abandoned,
-			},
-		}
+		Game:  gameStatus(persistentSession, gameId, game, abandoned),
 	}
 
 	text, _ := json.Marshal(response)
@@ -146,7 +143,9 @@ func SendGameResponse(session *melody.Session, cmd *Command, gameId string, game
 }
 
 func subscribeToGame(ctx *context.Context, rdb *redis.Client, session *melody.Session, gameId string) chan bool {
-	doneChan := make(chan bool)
+	// The watcher can stop by itself when the player is removed from the game, so unsubscribing mustn't
+	// wait on it
+	doneChan := make(chan bool, 1)
 
 	log.Printf("Subscribing to gameId: %s", gameId)
 
@@ -179,15 +178,23 @@ func openSession(ctx *context.Context, rdb *redis.Client, session *melody.Sessio
 		// Load the game from redis
 		game, err := LoadGame(ctx, rdb, gameId)
 
-		// If the game doesn't exist, detach it from the session
-		if err != nil || game.State == GameAbandoned {
+		// If the game doesn't exist, or the player was removed from it while they were away, detach it from
+		// the session
+		if err != nil || game.State == GameAbandoned || !persistentSession.Spectating && GetPlayerIndex(game, persistentSession.PlayerName) == -1 {
 			persistentSession.ActiveGame = ""
+			persistentSession.Spectating = false
 			SetPersistentSession(ctx, session, rdb, persistentSession)
 		} else {
 			// Re-subscribe to the active game
 			persistentSession.UnsubChan = subscribeToGame(ctx, rdb, session, gameId)
 			SetPersistentSession(ctx, session, rdb, persistentSession)
 
+			// Take the player's seat back. Everyone else gets the update through the subscription.
+			PlayerReconnected(ctx, rdb, persistentSession)
+			if reconnected, err := LoadGame(ctx, rdb, gameId); err == nil {
+				game = reconnected
+			}
+
 			SendGameUpdate(session, gameId, game, false)
 		}
 	}
@@ -214,14 +221,24 @@ func createGame(ctx *context.Context, rdb *redis.Client, session *melody.Session
 	fmt.Println(gamePneumonic)
 
 	if playerName, ok := cmd.Data["playerName"]; ok {
-		game := EmptyGame(gameId, gamePneumonic)
+		if playerName == "" {
+			return errors.New("You need a name to create a game")
+		}
+
+		seed := rand.Int63()
+
+		game := EmptyGame(gameId, gamePneumonic, seed)
 		game = AddPlayer(game, playerName)
+		game.Players[0].SessionId = persistentSession.SessionId
+		game.Host = playerName
 
 		SaveGame(ctx, rdb, gameId, game)
+		StartReplay(ctx, rdb, gameId, seed)
+		RecordCommand(ctx, rdb, gameId, playerName, cmd, game, nil)
 
-		persistentSession.GameHost = true
 		persistentSession.PlayerName = playerName
 		persistentSession.ActiveGame = gameId
+		persistentSession.Spectating = false
 		persistentSession.UnsubChan = subscribeToGame(ctx, rdb, session, gameId)
 
 		SetPersistentSession(ctx, session, rdb, persistentSession)
@@ -243,12 +260,20 @@ func joinGame(ctx *context.Context, rdb *redis.Client, session *melody.Session,
 	playerName, ok2 := cmd.Data["playerName"]
 
 	if ok1 && ok2 {
+		if playerName == "" {
+			return errors.New("You need a name to join a game")
+		}
+
 		if GameExists(ctx, rdb, gameId) {
 			game, err := LoadGame(ctx, rdb, gameId)
 			if err != nil {
 				return errors.New("Error fetching game")
 			}
 
+			if IsBanned(game, persistentSession.SessionId) {
+				return errors.New("You've been banned from this game")
+			}
+
 			for _, player := range game.Players {
 				if player.Name == playerName {
 					return errors.New("Player already exists")
@@ -256,11 +281,19 @@ func joinGame(ctx *context.Context, rdb *redis.Client, session *melody.Session,
 			}
 
 			game = AddPlayer(game, playerName)
+			game.Players[len(game.Players)-1].SessionId = persistentSession.SessionId
+
+			// Joining a game that's been left without a host takes over hosting it
+			if game.Host == "" {
+				game.Host = playerName
+			}
+
 			SaveGame(ctx, rdb, gameId, game)
+			RecordCommand(ctx, rdb, gameId, playerName, cmd, game, nil)
 
-			persistentSession.GameHost = false
 			persistentSession.PlayerName = playerName
 			persistentSession.ActiveGame = gameId
+			persistentSession.Spectating = false
 			persistentSession.UnsubChan = subscribeToGame(ctx, rdb, session, gameId)
 
 			SetPersistentSession(ctx, session, rdb, persistentSession)
@@ -275,12 +308,91 @@ func joinGame(ctx *context.Context, rdb *redis.Client, session *melody.Session,
 	return errors.New("Expected gameId and playerName to be supplied")
 }
 
+func spectateGame(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+	persistentSession, err := GetPersistentSession(session)
+	if err != nil {
+		return errors.New("Something went wrong loading your session")
+	}
+
+	gameId, ok := cmd.Data["gameId"]
+	if !ok {
+		return errors.New("Expected gameId to be supplied")
+	}
+
+	// A player's seat would be left with nobody to take it back
+	if persistentSession.ActiveGame != "" && !persistentSession.Spectating {
+		return errors.New("Leave your game before spectating another")
+	}
+
+	game, err := LoadGame(ctx, rdb, gameId)
+	if err != nil || game.State == GameAbandoned {
+		return errors.New("Game not found")
+	}
+
+	if IsBanned(game, persistentSession.SessionId) {
+		return errors.New("You've been banned from this game")
+	}
+
+	// Stop watching the game the session was spectating before
+	if persistentSession.UnsubChan != nil {
+		persistentSession.UnsubChan <- true
+	}
+
+	// Spectators have no name at the table, so they can't make moves for anyone
+	persistentSession.PlayerName = ""
+	persistentSession.ActiveGame = gameId
+	persistentSession.Spectating = true
+	persistentSession.UnsubChan = subscribeToGame(ctx, rdb, session, gameId)
+
+	SetPersistentSession(ctx, session, rdb, persistentSession)
+	SendGameResponse(session, cmd, gameId, game, false)
+
+	return nil
+}
+
+// stopSpectating detaches a spectator from the game they're watching
+func stopSpectating(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command, persistentSession *PersistentSession) error {
+	gameId := persistentSession.ActiveGame
+
+	if persistentSession.UnsubChan != nil {
+		persistentSession.UnsubChan <- true
+	}
+
+	persistentSession.ActiveGame = ""
+	persistentSession.Spectating = false
+	persistentSession.UnsubChan = nil
+
+	SetPersistentSession(ctx, session, rdb, persistentSession)
+	SendGameResponse(session, cmd, gameId, &Game{}, true)
+
+	return nil
+}
+
+// removeFromGame returns the game once the player has left it, both before and after any bots the turn
+// passed to have moved, along with the moves they made
+func removeFromGame(game *Game, playerName string) (*Game, *Game, []BotMove) {
+	game = RemovePlayer(game, playerName)
+
+	if ActivePlayerCount(game) == 1 && game.State == GamePlaying {
+		game = EndGame(game)
+	}
+
+	left := game
+	game, moves := RunBots(game)
+
+	return left, game, moves
+}
+
 func leaveGame(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
 	persistentSession, err := GetPersistentSession(session)
 	if err != nil {
 		return errors.New("Something went wrong loading your session")
 	}
 
+	if persistentSession.Spectating {
+		return stopSpectating(ctx, rdb, session, cmd, persistentSession)
+	}
+
 	gameId := persistentSession.ActiveGame
 	playerName := persistentSession.PlayerName
 
@@ -290,15 +402,16 @@ func leaveGame(ctx *context.Context, rdb *redis.Client, session *melody.Session,
 			return errors.New("Can't find that game")
 		}
 
-		game = RemovePlayer(game, playerName)
-
-		if len(game.Players) == 1 && game.State == GamePlaying {
-			game = EndGame(game)
-		}
+		left, game, botMoves := removeFromGame(game, playerName)
 
 		SaveGame(ctx, rdb, gameId, game)
+		RecordCommand(ctx, rdb, gameId, playerName, cmd, left, nil)
+		recordBotMoves(ctx, rdb, gameId, botMoves)
+
+		if persistentSession.UnsubChan != nil {
+			persistentSession.UnsubChan <- true
+		}
 
-		persistentSession.GameHost = false
 		persistentSession.PlayerName = playerName
 		persistentSession.ActiveGame = ""
 		persistentSession.UnsubChan = nil
@@ -314,12 +427,119 @@ func leaveGame(ctx *context.Context, rdb *redis.Client, session *melody.Session,
 	return errors.New("Expected gameId and playerName to be supplied")
 }
 
+// applyAction applies an action by the session's player to their game, and sends them the result. The
+// game is updated transactionally, since actions like calling UNO or jumping in can race each other.
+func applyAction(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command, action Action) error {
+	persistentSession, err := GetPersistentSession(session)
+	if err != nil {
+		return errors.New("Something went wrong loading your session")
+	}
+
+	// Spectators can watch, but not play
+	if persistentSession.Spectating {
+		return errors.New("You're spectating this game")
+	}
+
+	gameId := persistentSession.ActiveGame
+
+	// Any bots waiting on the action take their turns as part of the same update
+	var applied *Game
+	var botMoves []BotMove
+
+	game, err := UpdateGame(ctx, rdb, gameId, func(game *Game) (*Game, error) {
+		action.Player = GetPlayerIndex(game, persistentSession.PlayerName)
+
+		game, _, err := Apply(game, action)
+		if err != nil {
+			return game, err
+		}
+
+		applied = game
+		game, botMoves = RunBots(game)
+
+		return gatherEvents(game, applied.Events, botMoves), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	RecordCommand(ctx, rdb, gameId, persistentSession.PlayerName, cmd, applied, &action)
+	recordBotMoves(ctx, rdb, gameId, botMoves)
+
+	// Players who disconnected in the lobby, or after the last match, are watched once play starts
+	if action.Kind == StartAction {
+		watchDisconnectedPlayers(ctx, rdb, gameId, game)
+	}
+
+	SendGameResponse(session, cmd, gameId, game, false)
+
+	return nil
+}
+
+// botVerbs are the commands a person would have sent to make each kind of move a bot makes
+var botVerbs = map[ActionKind]string{
+	PlayAction:        "playCards",
+	DrawAction:        "drawCard",
+	PassAction:        "doneDrawing",
+	ChooseColorAction: "chooseColor",
+	SwapHandsAction:   "chooseSwapTarget",
+	CallUnoAction:     "callUno",
+	CatchUnoAction:    "catchUno",
+	ChallengeAction:   "challengeWildFour",
+}
+
+// recordBotMoves adds the moves bots made to the game's replay log
+func recordBotMoves(ctx *context.Context, rdb *redis.Client, gameId string, moves []BotMove) {
+	for _, move := range moves {
+		action := move.Action
+		player := move.Game.Players[action.Player].Name
+
+		RecordCommand(ctx, rdb, gameId, player, &Command{Verb: botVerbs[action.Kind]}, move.Game, &action)
+	}
+}
+
 func startGame(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
 	persistentSession, err := GetPersistentSession(session)
 	if err != nil {
 		return errors.New("Something went wrong loading your session")
 	}
 
+	game, err := LoadGame(ctx, rdb, persistentSession.ActiveGame)
+	if err != nil {
+		return errors.New("Error fetching game")
+	}
+
+	if !isHost(persistentSession, game) {
+		return errors.New("Only the game host can start the game")
+	}
+
+	return applyAction(ctx, rdb, session, cmd, Action{Kind: StartAction})
+}
+
+func nextRound(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+	persistentSession, err := GetPersistentSession(session)
+	if err != nil {
+		return errors.New("Something went wrong loading your session")
+	}
+
+	game, err := LoadGame(ctx, rdb, persistentSession.ActiveGame)
+	if err != nil {
+		return errors.New("Error fetching game")
+	}
+
+	if !isHost(persistentSession, game) {
+		return errors.New("Only the game host can start the next round")
+	}
+
+	return applyAction(ctx, rdb, session, cmd, Action{Kind: NextRoundAction})
+}
+
+func restartGame(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+	persistentSession, err := GetPersistentSession(session)
+	if err != nil {
+		return errors.New("Something went wrong loading your session")
+	}
+
 	gameId := persistentSession.ActiveGame
 
 	game, err := LoadGame(ctx, rdb, gameId)
@@ -327,20 +547,20 @@ func startGame(ctx *context.Context, rdb *redis.Client, session *melody.Session,
 		return errors.New("Error fetching game")
 	}
 
-	if !persistentSession.GameHost {
-		return errors.New("Only the game host can start the game")
+	if !isHost(persistentSession, game) {
+		return errors.New("Only the game host can restart the game")
 	}
 
-	game = DrawHands(game)
-	game = StartGame(game)
+	game = ResetMatch(game)
 
 	SaveGame(ctx, rdb, gameId, game)
+	RecordCommand(ctx, rdb, gameId, persistentSession.PlayerName, cmd, game, nil)
 	SendGameResponse(session, cmd, gameId, game, false)
 
 	return nil
 }
 
-func restartGame(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+func updateSettings(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
 	persistentSession, err := GetPersistentSession(session)
 	if err != nil {
 		return errors.New("Something went wrong loading your session")
@@ -353,13 +573,51 @@ func restartGame(ctx *context.Context, rdb *redis.Client, session *melody.Sessio
 		return errors.New("Error fetching game")
 	}
 
-	if !persistentSession.GameHost {
-		return errors.New("Only the game host can restart the game")
+	if !isHost(persistentSession, game) {
+		return errors.New("Only the game host can change the settings")
 	}
 
-	game.State = GameCreated
+	game, err = UpdateSettings(game, cmd.Data)
+	if err != nil {
+		return err
+	}
 
 	SaveGame(ctx, rdb, gameId, game)
+	RecordCommand(ctx, rdb, gameId, persistentSession.PlayerName, cmd, game, nil)
+	SendGameResponse(session, cmd, gameId, game, false)
+
+	return nil
+}
+
+func addBot(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+	persistentSession, err := GetPersistentSession(session)
+	if err != nil {
+		return errors.New("Something went wrong loading your session")
+	}
+
+	gameId := persistentSession.ActiveGame
+
+	game, err := LoadGame(ctx, rdb, gameId)
+	if err != nil {
+		return errors.New("Error fetching game")
+	}
+
+	if !isHost(persistentSession, game) {
+		return errors.New("Only the game host can add bots")
+	}
+
+	difficulty, err := parseBotDifficulty(cmd.Data["difficulty"])
+	if err != nil {
+		return err
+	}
+
+	game, err = AddBot(game, difficulty)
+	if err != nil {
+		return err
+	}
+
+	SaveGame(ctx, rdb, gameId, game)
+	RecordCommand(ctx, rdb, gameId, game.Players[len(game.Players)-1].Name, cmd, game, nil)
 	SendGameResponse(session, cmd, gameId, game, false)
 
 	return nil
@@ -378,105 +636,284 @@ func endGame(ctx *context.Context, rdb *redis.Client, session *melody.Session, c
 		return errors.New("Error fetching game")
 	}
 
-	if !persistentSession.GameHost {
+	if !isHost(persistentSession, game) {
 		return errors.New("Only the game host can end the game")
 	}
 
 	game = EndGame(game)
 
 	SaveGame(ctx, rdb, gameId, game)
+	RecordCommand(ctx, rdb, gameId, persistentSession.PlayerName, cmd, game, nil)
 	SendGameResponse(session, cmd, gameId, game, true)
 
 	return nil
 }
 
-func playCard(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+func kickPlayer(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+	return removeByHost(ctx, rdb, session, cmd, false)
+}
+
+func banPlayer(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+	return removeByHost(ctx, rdb, session, cmd, true)
+}
+
+// removeByHost removes a player from the host's game, and bans them from joining again if ban is set.
+// Their session is detached from the game when it hears about the update.
+func removeByHost(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command, ban bool) error {
 	persistentSession, err := GetPersistentSession(session)
 	if err != nil {
 		return errors.New("Something went wrong loading your session")
 	}
 
-	cardIndex, ok1 := cmd.Data["cardIndex"]
-	wildColor, ok2 := cmd.Data["wildColor"]
-
-	if !ok1 && !ok2 {
-		return errors.New("Expected cardIndex and wildColor to be supplied")
+	playerName, ok := cmd.Data["playerName"]
+	if !ok {
+		return errors.New("Expected playerName to be supplied")
 	}
 
 	gameId := persistentSession.ActiveGame
+
 	game, err := LoadGame(ctx, rdb, gameId)
 	if err != nil {
 		return errors.New("Error fetching game")
 	}
 
-	if game.ActivePlayer != GetPlayerIndex(game, persistentSession.PlayerName) {
-		return errors.New("It's not your turn")
+	if !isHost(persistentSession, game) {
+		return errors.New("Only the game host can remove players")
 	}
 
-	index, err := strconv.ParseInt(cardIndex, 10, 32)
-	card := int(index)
-	if err != nil || card < 0 || card > len(game.Players[game.ActivePlayer].Cards) {
-		return errors.New("Invalid card index")
+	if playerName == persistentSession.PlayerName {
+		return errors.New("You can't remove yourself, leave the game instead")
 	}
 
-	game, err = PlayCard(game, card, wildColor)
-	if err != nil {
-		return err
+	if GetPlayerIndex(game, playerName) == -1 {
+		return errors.New("That player isn't in the game")
+	}
+
+	if ban {
+		game = BanPlayer(game, playerName)
 	}
 
+	left, game, botMoves := removeFromGame(game, playerName)
+
 	SaveGame(ctx, rdb, gameId, game)
+	RecordCommand(ctx, rdb, gameId, persistentSession.PlayerName, cmd, left, nil)
+	recordBotMoves(ctx, rdb, gameId, botMoves)
 	SendGameResponse(session, cmd, gameId, game, false)
 
 	return nil
 }
 
-func drawCard(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+func transferHost(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
 	persistentSession, err := GetPersistentSession(session)
 	if err != nil {
 		return errors.New("Something went wrong loading your session")
 	}
 
+	playerName, ok := cmd.Data["playerName"]
+	if !ok {
+		return errors.New("Expected playerName to be supplied")
+	}
+
 	gameId := persistentSession.ActiveGame
+
 	game, err := LoadGame(ctx, rdb, gameId)
 	if err != nil {
 		return errors.New("Error fetching game")
 	}
 
-	if game.ActivePlayer != GetPlayerIndex(game, persistentSession.PlayerName) {
-		return errors.New("It's not your turn")
+	if !isHost(persistentSession, game) {
+		return errors.New("Only the game host can hand over hosting")
 	}
 
-	game, err = DrawCard(game)
+	game, err = TransferHost(game, playerName)
 	if err != nil {
 		return err
 	}
 
 	SaveGame(ctx, rdb, gameId, game)
+	RecordCommand(ctx, rdb, gameId, persistentSession.PlayerName, cmd, game, nil)
 	SendGameResponse(session, cmd, gameId, game, false)
 
 	return nil
 }
 
+func playCard(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+	cardIndex, ok1 := cmd.Data["cardIndex"]
+	wildColor, ok2 := cmd.Data["wildColor"]
+
+	if !ok1 && !ok2 {
+		return errors.New("Expected cardIndex and wildColor to be supplied")
+	}
+
+	card, err := strconv.Atoi(cardIndex)
+	if err != nil {
+		return errors.New("Invalid card index")
+	}
+
+	return applyAction(ctx, rdb, session, cmd, Action{Kind: PlayAction, Cards: []int{card}, Color: Color(wildColor)})
+}
+
+func playCards(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+	cardIndexes, ok := cmd.Data["cardIndexes"]
+	if !ok {
+		return errors.New("Expected cardIndexes to be supplied")
+	}
+
+	// Card indexes are sent as a comma separated list, in the order they're played
+	indexes := []int{}
+	for _, cardIndex := range strings.Split(cardIndexes, ",") {
+		index, err := strconv.Atoi(strings.TrimSpace(cardIndex))
+		if err != nil {
+			return errors.New("Invalid card index")
+		}
+
+		indexes = append(indexes, index)
+	}
+
+	return applyAction(ctx, rdb, session, cmd, Action{Kind: PlayAction, Cards: indexes, Color: Color(cmd.Data["wildColor"])})
+}
+
+func jumpIn(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+	cardIndex, ok1 := cmd.Data["cardIndex"]
+	discardPileCount, ok2 := cmd.Data["discardPileCount"]
+
+	if !ok1 || !ok2 {
+		return errors.New("Expected cardIndex and discardPileCount to be supplied")
+	}
+
+	card, err1 := strconv.Atoi(cardIndex)
+	seenCount, err2 := strconv.Atoi(discardPileCount)
+	if err1 != nil || err2 != nil {
+		return errors.New("Expected cardIndex and discardPileCount to be numbers")
+	}
+
+	// Two players may jump in on the same card at once. Only the first update is accepted, and the
+	// discard pile count lets the engine reject the second even if it arrives later.
+	return applyAction(ctx, rdb, session, cmd, Action{Kind: JumpInAction, Cards: []int{card}, DiscardPileCount: seenCount})
+}
+
+func drawCard(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+	return applyAction(ctx, rdb, session, cmd, Action{Kind: DrawAction})
+}
+
 func doneDrawing(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+	return applyAction(ctx, rdb, session, cmd, Action{Kind: PassAction})
+}
+
+func chooseSwapTarget(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+	targetPlayer, ok := cmd.Data["targetPlayer"]
+	if !ok {
+		return errors.New("Expected targetPlayer to be supplied")
+	}
+
+	target, err := strconv.Atoi(targetPlayer)
+	if err != nil {
+		return errors.New("Invalid target player")
+	}
+
+	return applyAction(ctx, rdb, session, cmd, Action{Kind: SwapHandsAction, Target: target})
+}
+
+func callUno(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+	// UNO can be called out of turn, possibly at the same moment someone tries to catch the player
+	return applyAction(ctx, rdb, session, cmd, Action{Kind: CallUnoAction})
+}
+
+func catchUno(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+	targetPlayer, ok := cmd.Data["targetPlayer"]
+	if !ok {
+		return errors.New("Expected targetPlayer to be supplied")
+	}
+
+	target, err := strconv.Atoi(targetPlayer)
+	if err != nil {
+		return errors.New("Invalid target player")
+	}
+
+	return applyAction(ctx, rdb, session, cmd, Action{Kind: CatchUnoAction, Target: target})
+}
+
+func challengeWildFour(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+	return applyAction(ctx, rdb, session, cmd, Action{Kind: ChallengeAction})
+}
+
+func chooseColor(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+	color, ok := cmd.Data["color"]
+	if !ok {
+		return errors.New("Expected color to be supplied")
+	}
+
+	return applyAction(ctx, rdb, session, cmd, Action{Kind: ChooseColorAction, Color: Color(color)})
+}
+
+func getReplay(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
 	persistentSession, err := GetPersistentSession(session)
 	if err != nil {
 		return errors.New("Something went wrong loading your session")
 	}
 
-	gameId := persistentSession.ActiveGame
-	game, err := LoadGame(ctx, rdb, gameId)
+	// Replays of other games can be fetched by their game id
+	gameId, ok := cmd.Data["gameId"]
+	if !ok {
+		gameId = persistentSession.ActiveGame
+	}
+
+	replay, err := LoadReplay(ctx, rdb, gameId)
+	if err != nil {
+		return err
+	}
+
+	text, _ := json.Marshal(ReplayResponse{
+		ReqId:  cmd.ReqId,
+		Verb:   cmd.Verb,
+		Replay: *replay,
+	})
+	session.Write(text)
+
+	return nil
+}
+
+func getHint(ctx *context.Context, rdb *redis.Client, session *melody.Session, cmd *Command) error {
+	persistentSession, err := GetPersistentSession(session)
+	if err != nil {
+		return errors.New("Something went wrong loading your session")
+	}
+
+	if persistentSession.Spectating {
+		return errors.New("You're spectating this game")
+	}
+
+	game, err := LoadGame(ctx, rdb, persistentSession.ActiveGame)
 	if err != nil {
 		return errors.New("Error fetching game")
 	}
 
-	if game.ActivePlayer != GetPlayerIndex(game, persistentSession.PlayerName) {
-		return errors.New("It's not your turn")
+	if !game.Rules.AllowHints {
+		return errors.New("Hints are turned off for this game")
 	}
 
-	game = DoneDrawing(game)
+	playerIndex := GetPlayerIndex(game, persistentSession.PlayerName)
+	if playerIndex == -1 {
+		return errors.New("You aren't playing in this game")
+	}
 
-	SaveGame(ctx, rdb, gameId, game)
-	SendGameResponse(session, cmd, gameId, game, false)
+	// Hints are worked out from the player's own view, so they can't give away anyone else's cards
+	hint, err := GetHint(GetPlayersGame(game, playerIndex))
+	if err != nil {
+		return err
+	}
+
+	sendResponse(session, Response{
+		ReqId: cmd.ReqId,
+		Verb:  cmd.Verb,
+		Data: map[string]string{
+			"action":    string(hint.Action),
+			"cardIndex": strconv.Itoa(hint.CardIndex),
+			"wildColor": string(hint.WildColor),
+			"target":    strconv.Itoa(hint.Target),
+			"reason":    hint.Reason,
+		},
+	})
 
 	return nil
 }
@@ -505,6 +942,11 @@ func DispatchMessage(ctx *context.Context, rdb *redis.Client, session *melody.Se
 		err = joinGame(ctx, rdb, session, &cmd)
 		break
 
+	case "spectateGame":
+		log.Println("Spectating a game")
+		err = spectateGame(ctx, rdb, session, &cmd)
+		break
+
 	case "leaveGame":
 		log.Println("Player leaving a game")
 		err = leaveGame(ctx, rdb, session, &cmd)
@@ -515,21 +957,61 @@ func DispatchMessage(ctx *context.Context, rdb *redis.Client, session *melody.Se
 		err = startGame(ctx, rdb, session, &cmd)
 		break
 
+	case "nextRound":
+		log.Println("Starting the next round")
+		err = nextRound(ctx, rdb, session, &cmd)
+		break
+
 	case "restartGame":
 		log.Println("Restarting the game")
 		err = restartGame(ctx, rdb, session, &cmd)
 		break
 
+	case "updateSettings":
+		log.Println("Updating game settings")
+		err = updateSettings(ctx, rdb, session, &cmd)
+		break
+
+	case "addBot":
+		log.Println("Adding a bot")
+		err = addBot(ctx, rdb, session, &cmd)
+		break
+
 	case "endGame":
 		log.Println("Ending the game")
 		err = endGame(ctx, rdb, session, &cmd)
 		break
 
+	case "kickPlayer":
+		log.Println("Kicking a player")
+		err = kickPlayer(ctx, rdb, session, &cmd)
+		break
+
+	case "banPlayer":
+		log.Println("Banning a player")
+		err = banPlayer(ctx, rdb, session, &cmd)
+		break
+
+	case "transferHost":
+		log.Println("Handing over hosting")
+		err = transferHost(ctx, rdb, session, &cmd)
+		break
+
 	case "playCard":
 		log.Println("Playing a card")
 		err = playCard(ctx, rdb, session, &cmd)
 		break
 
+	case "playCards":
+		log.Println("Playing several cards")
+		err = playCards(ctx, rdb, session, &cmd)
+		break
+
+	case "jumpIn":
+		log.Println("Jumping in")
+		err = jumpIn(ctx, rdb, session, &cmd)
+		break
+
 	case "drawCard":
 		log.Println("Drawing a card")
 		err = drawCard(ctx, rdb, session, &cmd)
@@ -540,6 +1022,41 @@ func DispatchMessage(ctx *context.Context, rdb *redis.Client, session *melody.Se
 		err = doneDrawing(ctx, rdb, session, &cmd)
 		break
 
+	case "chooseSwapTarget":
+		log.Println("Choosing a player to swap hands with")
+		err = chooseSwapTarget(ctx, rdb, session, &cmd)
+		break
+
+	case "chooseColor":
+		log.Println("Choosing the starting color")
+		err = chooseColor(ctx, rdb, session, &cmd)
+		break
+
+	case "callUno":
+		log.Println("Calling UNO")
+		err = callUno(ctx, rdb, session, &cmd)
+		break
+
+	case "catchUno":
+		log.Println("Catching a player who didn't call UNO")
+		err = catchUno(ctx, rdb, session, &cmd)
+		break
+
+	case "challengeWildFour":
+		log.Println("Challenging a wild+4")
+		err = challengeWildFour(ctx, rdb, session, &cmd)
+		break
+
+	case "getHint":
+		log.Println("Fetching a hint")
+		err = getHint(ctx, rdb, session, &cmd)
+		break
+
+	case "getReplay":
+		log.Println("Fetching a game replay")
+		err = getReplay(ctx, rdb, session, &cmd)
+		break
+
 	default:
 		err = errors.New("Unrecognized command")
 		break
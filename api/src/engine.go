@@ -0,0 +1,246 @@
+package main
+
+type ActionKind string
+
+const (
+	// Deal and start a round, starting a new match if the last one is over
+	StartAction ActionKind = "start"
+
+	// Deal and start the next round of a match
+	NextRoundAction ActionKind = "nextRound"
+
+	// Play one or more cards from the player's hand
+	PlayAction ActionKind = "play"
+
+	// Play a card identical to the top of the discard pile out of turn
+	JumpInAction ActionKind = "jumpIn"
+
+	// Draw a card, and pass after drawing
+	DrawAction ActionKind = "draw"
+	PassAction ActionKind = "pass"
+
+	// Choose the color of a wild turned over to start the discard pile
+	ChooseColorAction ActionKind = "chooseColor"
+
+	// Choose who to swap hands with after playing a 7 under Seven-O
+	SwapHandsAction ActionKind = "swapHands"
+
+	CallUnoAction   ActionKind = "callUno"
+	CatchUnoAction  ActionKind = "catchUno"
+	ChallengeAction ActionKind = "challenge"
+)
+
+// Action is a move made by a player. Only the fields used by the kind of action are set.
+type Action struct {
+	Kind   ActionKind `json:"kind"`
+	Player int        `json:"player"`
+
+	// Indexes of the cards played from the player's hand, in the order they're played
+	Cards []int `json:"cards,omitempty"`
+
+	// The color chosen for a wild
+	Color Color `json:"color,omitempty"`
+
+	// The player to swap hands with, or to catch not calling UNO
+	Target int `json:"target"`
+
+	// The size of the discard pile the player saw when they jumped in
+	DiscardPileCount int `json:"discardPileCount,omitempty"`
+}
+
+type EventKind string
+
+const (
+	// A round was dealt. The card is the one turned over to start the discard pile.
+	RoundStartedEvent EventKind = "roundStarted"
+
+	CardPlayedEvent  EventKind = "cardPlayed"
+	JumpedInEvent    EventKind = "jumpedIn"
+	ColorChosenEvent EventKind = "colorChosen"
+
+	// A card was drawn. The card is only shown to the player who drew it.
+	CardDrawnEvent  EventKind = "cardDrawn"
+	TurnPassedEvent EventKind = "turnPassed"
+
+	// The discard pile was shuffled to make a new draw pile. The count is the size of the new pile.
+	DrawPileReshuffledEvent EventKind = "drawPileReshuffled"
+
+	DirectionReversedEvent EventKind = "directionReversed"
+	PlayerSkippedEvent     EventKind = "playerSkipped"
+
+	// The player must draw the count of cards, unless they can pass the penalty on
+	DrawPenaltyEvent EventKind = "drawPenalty"
+
+	HandsSwappedEvent EventKind = "handsSwapped"
+	HandsRotatedEvent EventKind = "handsRotated"
+
+	UnoCalledEvent EventKind = "unoCalled"
+	UnoCaughtEvent EventKind = "unoCaught"
+
+	// A wild+4 was challenged. The target is the player who played it.
+	ChallengedEvent EventKind = "challenged"
+
+	RoundWonEvent         EventKind = "roundWon"
+	PlayerEliminatedEvent EventKind = "playerEliminated"
+	MatchWonEvent         EventKind = "matchWon"
+)
+
+// Event is something that happened in the game as the result of an action. Events that aren't about a
+// particular player have a player of -1.
+type Event struct {
+	Kind   EventKind `json:"kind"`
+	Player int       `json:"player"`
+	Target int       `json:"target"`
+	Card   Card      `json:"card"`
+	Color  Color     `json:"color,omitempty"`
+	Count  int       `json:"count,omitempty"`
+	Guilty bool      `json:"guilty,omitempty"`
+}
+
+// emit records an event caused by the action being applied to the game
+func emit(game *Game, event Event) {
+	game.Events = append(game.Events, event)
+}
+
+// cloneGame returns a deep copy of the game, so it can be changed without affecting the original
+func cloneGame(game *Game) *Game {
+	clone := *game
+
+	if game.Players != nil {
+		clone.Players = make([]Player, len(game.Players))
+
+		for i, player := range game.Players {
+			player.Cards = cloneCards(player.Cards)
+
+			if player.RoundScores != nil {
+				player.RoundScores = append([]int{}, player.RoundScores...)
+			}
+
+			clone.Players[i] = player
+		}
+	}
+
+	clone.DrawPile = cloneCards(game.DrawPile)
+	clone.DiscardPile = cloneCards(game.DiscardPile)
+
+	if game.BannedSessions != nil {
+		clone.BannedSessions = append([]string{}, game.BannedSessions...)
+	}
+
+	if game.Events != nil {
+		clone.Events = append([]Event{}, game.Events...)
+	}
+
+	if game.WildDrawFour != nil {
+		play := *game.WildDrawFour
+		play.Hand = cloneCards(play.Hand)
+		clone.WildDrawFour = &play
+	}
+
+	if game.Challenge != nil {
+		challenge := *game.Challenge
+		challenge.Hand = cloneCards(challenge.Hand)
+		clone.Challenge = &challenge
+	}
+
+	return &clone
+}
+
+func cloneCards(cards []Card) []Card {
+	if cards == nil {
+		return nil
+	}
+
+	return append([]Card{}, cards...)
+}
+
+// Apply returns the game that results from a player taking an action, along with the events it caused.
+// The game passed in is left untouched, so a rejected action has no effect.
+func Apply(game *Game, action Action) (*Game, []Event, error) {
+	if action.Player < 0 || action.Player >= len(game.Players) {
+		return game, nil, &GameError{message: "You aren't playing in this game"}
+	}
+
+	// Eliminated players sit out the rest of the match, though they can still move the game on
+	eliminated := game.Players[action.Player].Eliminated
+	if eliminated && action.Kind != StartAction && action.Kind != NextRoundAction {
+		return game, nil, &GameError{message: "You've been eliminated"}
+	}
+
+	next := cloneGame(game)
+	next.Events = []Event{}
+
+	var err error
+
+	switch action.Kind {
+	case StartAction:
+		// A game can only be started from the lobby, or played again once the match is over
+		if next.State != GameCreated && next.State != GameComplete {
+			return game, nil, &GameError{message: "The game has already started"}
+		}
+
+		// Playing again after a match has been won starts a new match
+		if next.State == GameComplete {
+			next = ResetMatch(next)
+		}
+
+		// The deal has to be checked before the cards are dealt
+		if err = validateStart(next); err == nil {
+			next, err = StartGame(DrawHands(next))
+		}
+
+	case NextRoundAction:
+		next, err = NextRound(next)
+
+	case PlayAction, DrawAction, PassAction:
+		if next.State != GamePlaying {
+			return game, nil, &GameError{message: "The game isn't being played"}
+		}
+
+		if action.Player != next.ActivePlayer {
+			return game, nil, &GameError{message: "It's not your turn"}
+		}
+
+		switch action.Kind {
+		case PlayAction:
+			next, err = PlayCards(next, action.Cards, action.Color)
+
+		case DrawAction:
+			next, err = DrawCard(next)
+
+		case PassAction:
+			next, err = DoneDrawing(next)
+		}
+
+	case JumpInAction:
+		if len(action.Cards) != 1 {
+			return game, nil, &GameError{message: "You can only jump in with one card"}
+		}
+
+		next, err = JumpIn(next, action.Player, action.Cards[0], action.DiscardPileCount)
+
+	case ChooseColorAction:
+		next, err = ChooseStartingColor(next, action.Player, action.Color)
+
+	case SwapHandsAction:
+		next, err = ChooseSwapTarget(next, action.Player, action.Target)
+
+	case CallUnoAction:
+		next, err = CallUno(next, action.Player)
+
+	case CatchUnoAction:
+		next, err = CatchUno(next, action.Player, action.Target)
+
+	case ChallengeAction:
+		next, err = ChallengeWildFour(next, action.Player)
+
+	default:
+		return game, nil, &GameError{message: "Unknown action: " + string(action.Kind)}
+	}
+
+	if err != nil {
+		return game, nil, err
+	}
+
+	return next, next.Events, nil
+}
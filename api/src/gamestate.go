@@ -32,7 +32,12 @@ func SaveGame(ctx *context.Context, rdb *redis.Client, gameId string, game *Game
 }
 
 func LoadGame(ctx *context.Context, rdb *redis.Client, gameId string) (*Game, error) {
-	var game Game
+	return loadGame(ctx, rdb, gameId)
+}
+
+func loadGame(ctx *context.Context, rdb redis.Cmdable, gameId string) (*Game, error) {
+	// Games stored before a rule existed pick up its default value
+	game := Game{Rules: DefaultRuleSet()}
 
 	stored, err := rdb.Get(*ctx, "game:"+gameId).Result()
 	if err != nil || stored == "" {
@@ -44,9 +49,63 @@ func LoadGame(ctx *context.Context, rdb *redis.Client, gameId string) (*Game, er
 		return nil, errors.New("Unable to unmarshal game")
 	}
 
+	// Games stored before the host was recorded are hosted by whoever created them. A game that has
+	// been left without a host since then stays that way.
+	if !game.HostRecorded {
+		if game.Host == "" && len(game.Players) > 0 && game.Players[0].Bot == NoBot {
+			game.Host = game.Players[0].Name
+		}
+
+		game.HostRecorded = true
+	}
+
 	return &game, nil
 }
 
+// UpdateGame loads a game, applies the update to it and saves the result as a single transaction. If
+// another command changes the game in the meantime, the update is rejected rather than overwriting
+// the other change.
+func UpdateGame(ctx *context.Context, rdb *redis.Client, gameId string, update func(*Game) (*Game, error)) (*Game, error) {
+	var updated *Game
+
+	err := rdb.Watch(*ctx, func(tx *redis.Tx) error {
+		game, err := loadGame(ctx, tx, gameId)
+		if err != nil {
+			return err
+		}
+
+		updated, err = update(game)
+		if err != nil {
+			return err
+		}
+
+		stored, _ := json.Marshal(updated)
+
+		_, err = tx.TxPipelined(*ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(*ctx, "game:"+gameId, []byte(stored), 12*time.Hour)
+			return nil
+		})
+
+		return err
+	}, "game:"+gameId)
+
+	if err == redis.TxFailedErr {
+		return nil, errors.New("Someone else got there first")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Publish an event to the game:gameId topic to notify other players
+	err = rdb.Publish(*ctx, "game:"+gameId, "updated").Err()
+	if err != nil {
+		log.Println(err)
+	}
+
+	return updated, nil
+}
+
 func DeleteGame(ctx *context.Context, rdb *redis.Client, gameId string) error {
 	err := rdb.Del(*ctx, "game:"+gameId).Err()
 	if err != nil {
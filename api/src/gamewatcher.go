@@ -25,6 +25,13 @@ func WatchGame(ctx *context.Context, rdb *redis.Client, session *melody.Session,
 			}
 
 			SendGameUpdate(session, gameId, game, game.State == GameAbandoned)
+
+			// Players who've been removed from the game stop watching it
+			if detachRemovedPlayer(ctx, rdb, session, gameId, game) {
+				pubsub.Close()
+				return
+			}
+
 			break
 
 		case <-done:
@@ -35,3 +42,22 @@ func WatchGame(ctx *context.Context, rdb *redis.Client, session *melody.Session,
 	}
 
 }
+
+// detachRemovedPlayer detaches the session from the game if its player was removed from it, and returns
+// true if it was
+func detachRemovedPlayer(ctx *context.Context, rdb *redis.Client, session *melody.Session, gameId string, game *Game) bool {
+	persistentSession, err := GetPersistentSession(session)
+	if err != nil || persistentSession.Spectating || persistentSession.ActiveGame != gameId {
+		return false
+	}
+
+	if GetPlayerIndex(game, persistentSession.PlayerName) != -1 {
+		return false
+	}
+
+	persistentSession.ActiveGame = ""
+	persistentSession.UnsubChan = nil
+	SetPersistentSession(ctx, session, rdb, persistentSession)
+
+	return true
+}
@@ -0,0 +1,215 @@
+package main
+
+import "fmt"
+
+// Hint is a move suggested to a player, and the reason for it
+type Hint struct {
+	Action    ActionKind
+	CardIndex int
+	WildColor Color
+	Target    int
+	Reason    string
+}
+
+var colorNames = map[Color]string{
+	Red:    "red",
+	Green:  "green",
+	Blue:   "blue",
+	Yellow: "yellow",
+}
+
+// The names for each kind of card as they're said out loud, rather than as they're written in the notation
+var spokenKindNames = map[Kind]string{
+	Skip:         "skip",
+	Reverse:      "reverse",
+	DrawTwo:      "+2",
+	Wild:         "wild",
+	WildDrawFour: "wild+4",
+}
+
+// describeCard returns a card's name as it would be said out loud, like "red 5" or "blue +2"
+func describeCard(card Card) string {
+	if card.IsWild() {
+		return spokenKindNames[card.Kind]
+	}
+
+	if card.Kind == Number {
+		return fmt.Sprintf("%s %d", colorNames[card.Color], card.Value)
+	}
+
+	return colorNames[card.Color] + " " + spokenKindNames[card.Kind]
+}
+
+func describeCount(count int) string {
+	if count == 1 {
+		return "1 card"
+	}
+
+	return fmt.Sprintf("%d cards", count)
+}
+
+// mostHeldColor returns the color the player holds the most of, leaving out the card at skipIndex
+func mostHeldColor(cards []Card, skipIndex int) Color {
+	held := colorCounts(cards, skipIndex)
+
+	best := colors[0]
+	for _, color := range colors {
+		if held[color] > held[best] {
+			best = color
+		}
+	}
+
+	return best
+}
+
+// viewSeat returns the index of the player in their own view of the game
+func viewSeat(view *PlayersGame) int {
+	for i, player := range view.OtherPlayers {
+		if player.Name == view.You.Name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// viewNextSeat returns the next player after the seat going in the direction given, as nextSeat does for
+// the whole game
+func viewNextSeat(view *PlayersGame, seat int, direction GameDirection) int {
+	count := len(view.OtherPlayers)
+	next := seat
+
+	for range view.OtherPlayers {
+		next = ((next+int(direction))%count + count) % count
+
+		if !view.OtherPlayers[next].Eliminated {
+			break
+		}
+	}
+
+	return next
+}
+
+// GetHint suggests a move for a player. It only uses the player's own view of the game, so it knows no
+// more than they do.
+func GetHint(view *PlayersGame) (Hint, error) {
+	hint := Hint{CardIndex: -1, Target: -1}
+
+	if view.State != GamePlaying {
+		return hint, &GameError{message: "There's nothing to hint at until the round starts"}
+	}
+
+	seat := viewSeat(view)
+	if seat == -1 {
+		return hint, &GameError{message: "You aren't playing in this game"}
+	}
+
+	moves := view.LegalMoves
+	cards := view.You.Cards
+
+	switch {
+	case len(moves.SwapTargets) > 0:
+		target := moves.SwapTargets[0]
+		for _, i := range moves.SwapTargets {
+			if view.OtherPlayers[i].NumCards < view.OtherPlayers[target].NumCards {
+				target = i
+			}
+		}
+
+		hint.Action = SwapHandsAction
+		hint.Target = target
+		hint.Reason = fmt.Sprintf("Swap with %s, who only has %s", view.OtherPlayers[target].Name, describeCount(view.OtherPlayers[target].NumCards))
+
+	case view.ChoiceRequired == StartingColorChoice:
+		hint.Action = ChooseColorAction
+		hint.WildColor = mostHeldColor(cards, -1)
+		hint.Reason = fmt.Sprintf("Choose %s, the color you hold the most of", colorNames[hint.WildColor])
+
+	case moves.CanCallUno && !view.You.CalledUno:
+		hint.Action = CallUnoAction
+		hint.Reason = "Call UNO now, or you can be caught when you're down to one card"
+
+	case len(moves.CatchablePlayers) > 0:
+		target := moves.CatchablePlayers[0]
+
+		hint.Action = CatchUnoAction
+		hint.Target = target
+		hint.Reason = fmt.Sprintf("Catch %s, they didn't call UNO", view.OtherPlayers[target].Name)
+
+	case len(moves.JumpInCards) > 0:
+		hint.Action = JumpInAction
+		hint.CardIndex = moves.JumpInCards[0]
+		hint.Reason = fmt.Sprintf("Jump in with your %s, it matches the top card exactly", describeCard(cards[hint.CardIndex]))
+
+	case len(moves.PlayableCards) > 0:
+		hint = hintCard(view, seat)
+
+	// The wild+4 was played by the player before you
+	case moves.CanChallenge && view.OtherPlayers[viewNextSeat(view, seat, -view.GameDirection)].NumCards >= 5:
+		hint.Action = ChallengeAction
+		hint.Reason = fmt.Sprintf("Challenge the wild+4, %s has plenty of cards and may have held a match", view.OtherPlayers[viewNextSeat(view, seat, -view.GameDirection)].Name)
+
+	case moves.CanDraw && view.MustDraw > 0:
+		hint.Action = DrawAction
+		hint.Reason = fmt.Sprintf("You've nothing to pass the penalty on with, draw %s", describeCount(view.MustDraw))
+
+	case moves.CanDraw:
+		hint.Action = DrawAction
+		hint.Reason = "Nothing in your hand can be played, draw a card"
+
+	case moves.CanDoneDrawing:
+		hint.Action = PassAction
+		hint.Reason = "The card you drew can't be played, pass"
+
+	default:
+		return hint, &GameError{message: "There's nothing for you to do until it's your turn"}
+	}
+
+	return hint, nil
+}
+
+// hintCard suggests which card to play, the same way a hard bot chooses
+func hintCard(view *PlayersGame, seat int) Hint {
+	cards := view.You.Cards
+	held := colorCounts(cards, -1)
+
+	next := view.OtherPlayers[viewNextSeat(view, seat, view.GameDirection)]
+	threatened := next.NumCards <= 2
+
+	hint := Hint{Action: PlayAction, CardIndex: view.LegalMoves.PlayableCards[0], Target: -1}
+	bestScore := -1
+
+	for _, cardIndex := range view.LegalMoves.PlayableCards {
+		if score := cardScore(cards[cardIndex], HardBot, held, threatened); score > bestScore {
+			hint.CardIndex = cardIndex
+			bestScore = score
+		}
+	}
+
+	card := cards[hint.CardIndex]
+	name := describeCard(card)
+
+	switch {
+	case card.IsWild():
+		hint.WildColor = mostHeldColor(cards, hint.CardIndex)
+		hint.Reason = fmt.Sprintf("Play your %s and choose %s, the color you hold the most of", name, colorNames[hint.WildColor])
+
+		if card.Kind == WildDrawFour && threatened {
+			hint.Reason = fmt.Sprintf("Shed your %s while %s has %s, and choose %s", name, next.Name, describeCount(next.NumCards), colorNames[hint.WildColor])
+		}
+
+	case card.Kind != Number && threatened:
+		hint.Reason = fmt.Sprintf("Shed your %s while %s has %s", name, next.Name, describeCount(next.NumCards))
+
+	case card.Kind != Number:
+		hint.Reason = fmt.Sprintf("Shed your %s, it's worth 20 points to whoever goes out first", name)
+
+	case held[card.Color] > 1 && card.Color == mostHeldColor(cards, -1):
+		hint.Reason = fmt.Sprintf("Play the %s to stay on %s, the color you hold the most of", name, colorNames[card.Color])
+
+	default:
+		hint.Reason = fmt.Sprintf("Play the %s to get its points out of your hand", name)
+	}
+
+	return hint
+}
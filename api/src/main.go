@@ -17,6 +17,15 @@ import (
 var ctx = context.Background()
 
 func main() {
+	// The simulate command plays bot games straight through the engine, without starting the server
+	if len(os.Args) > 1 && os.Args[1] == "simulate" {
+		if err := runSimulation(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
 	rand.Seed(time.Now().Unix())
 
 	rdb := redis.NewClient(&redis.Options{
@@ -25,6 +34,10 @@ func main() {
 		DB:       0,  // use default DB
 	})
 
+	// Disconnected players' seats are held, and their turns timed out, from one place. This picks up
+	// the games that were being watched before a restart.
+	go WatchDisconnectedPlayers(&ctx, rdb)
+
 	r := gin.Default()
 	m := melody.New()
 
@@ -36,6 +49,16 @@ func main() {
 		m.HandleRequest(c.Writer, c.Request)
 	})
 
+	r.GET("/games/:gameId/replay", func(c *gin.Context) {
+		replay, err := LoadReplay(&ctx, rdb, c.Param("gameId"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, replay)
+	})
+
 	m.HandleMessage(func(s *melody.Session, msg []byte) {
 		// fmt.Printf("Got msg: %s\n", msg)
 		DispatchMessage(&ctx, rdb, s, msg)
@@ -50,7 +73,12 @@ func main() {
 		}
 
 		// Close the goroutine that is listening for state changes
-		persistentSession.UnsubChan <- true
+		if persistentSession.UnsubChan != nil {
+			persistentSession.UnsubChan <- true
+		}
+
+		// Hold the player's seat until they come back
+		PlayerDisconnected(&ctx, rdb, persistentSession)
 	})
 
 	r.Run(":" + os.Getenv("API_SERVER_PORT"))
@@ -0,0 +1,96 @@
+package main
+
+// LegalMoves lists every action a player can currently take. It's worked out with the same checks the
+// engine uses to accept or reject each move, so anything listed here will be accepted.
+type LegalMoves struct {
+	// Indexes of the cards in your hand you can play, and the colors you can choose when playing a wild
+	// (or when choosing the color of a starting wild)
+	PlayableCards []int   `json:"playableCards"`
+	WildColors    []Color `json:"wildColors"`
+
+	// Whether you can draw a card, or end your turn with doneDrawing
+	CanDraw        bool `json:"canDraw"`
+	CanDoneDrawing bool `json:"canDoneDrawing"`
+
+	// Indexes of the cards in your hand you can jump in with, even when it isn't your turn
+	JumpInCards []int `json:"jumpInCards"`
+
+	// Players you can swap hands with after playing a 7 under Seven-O
+	SwapTargets []int `json:"swapTargets"`
+
+	// UNO calls, the players you can catch not calling UNO, and whether you can challenge a wild+4
+	CanCallUno       bool  `json:"canCallUno"`
+	CatchablePlayers []int `json:"catchablePlayers"`
+	CanChallenge     bool  `json:"canChallenge"`
+}
+
+// GetLegalMoves returns every move the player at playerIndex can currently make
+func GetLegalMoves(game *Game, playerIndex int) LegalMoves {
+	moves := LegalMoves{
+		PlayableCards:    []int{},
+		WildColors:       []Color{},
+		JumpInCards:      []int{},
+		SwapTargets:      []int{},
+		CatchablePlayers: []int{},
+	}
+
+	if playerIndex < 0 || playerIndex >= len(game.Players) || game.State != GamePlaying || game.Players[playerIndex].Eliminated {
+		return moves
+	}
+
+	moves.CanCallUno = canCallUno(game, playerIndex)
+
+	for i, player := range game.Players {
+		if i != playerIndex && player.UnoVulnerable {
+			moves.CatchablePlayers = append(moves.CatchablePlayers, i)
+		}
+	}
+
+	if game.PendingChoice.Kind != NoChoice {
+		if game.PendingChoice.Player != playerIndex {
+			return moves
+		}
+
+		switch game.PendingChoice.Kind {
+		case SwapHandsChoice:
+			for i, player := range game.Players {
+				if i != playerIndex && !player.Eliminated {
+					moves.SwapTargets = append(moves.SwapTargets, i)
+				}
+			}
+
+		case StartingColorChoice:
+			moves.WildColors = append(moves.WildColors, colors[:]...)
+		}
+
+		return moves
+	}
+
+	cards := game.Players[playerIndex].Cards
+
+	if game.ActivePlayer == playerIndex {
+		for i, card := range cards {
+			if validatePlayCard(game, i) != nil {
+				continue
+			}
+
+			moves.PlayableCards = append(moves.PlayableCards, i)
+
+			if card.IsWild() && len(moves.WildColors) == 0 {
+				moves.WildColors = append(moves.WildColors, colors[:]...)
+			}
+		}
+
+		moves.CanDraw = validateDrawCard(game) == nil
+		moves.CanDoneDrawing = validateDoneDrawing(game) == nil
+		moves.CanChallenge = canChallenge(game, playerIndex)
+	}
+
+	for i := range cards {
+		if validateJumpIn(game, playerIndex, i) == nil {
+			moves.JumpInCards = append(moves.JumpInCards, i)
+		}
+	}
+
+	return moves
+}
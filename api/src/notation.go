@@ -0,0 +1,540 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GameRecord is everything needed to play a game back: the seed, the players in seat order, the rules and
+// the actions taken
+type GameRecord struct {
+	Seed    int64
+	Players []string
+	Rules   RuleSet
+	Actions []Action
+}
+
+// A game written in the notation starts with header tags, one per line, followed by one token per move:
+//
+//   [Seed "42"]
+//   [Player "Nia"]
+//   [Player "Eric"]
+//   [handSize "7"]
+//
+//   start Nia:R5 Eric:draw Eric:pass Nia:wild+4/B Eric:challenge
+//
+// Every rule is written as a tag named after the rule. Moves are written as the player's name, a colon and
+// the move. Cards are played by name (several cards are separated by commas), with the color chosen for a
+// wild after a slash. The other moves are draw, pass, jump/<card>, color/<color>, swap/<player>, uno,
+// catch/<player> and challenge. Dealing a round isn't done by a player, so it's written as just start or
+// nextRound. Names that contain spaces or punctuation are quoted.
+
+// NewRecordGame returns the game a record starts from, with its players seated and its rules set
+func NewRecordGame(record *GameRecord) *Game {
+	game := EmptyGame("", "", record.Seed)
+	game.Rules = record.Rules
+
+	for _, name := range record.Players {
+		game = AddPlayer(game, name)
+	}
+
+	return game
+}
+
+// FormatGame writes a game record in the notation. The actions are played back to find the cards they
+// played, so an action the engine rejects is an error.
+func FormatGame(record *GameRecord) (string, error) {
+	var text strings.Builder
+
+	fmt.Fprintf(&text, "[Seed %s]\n", strconv.Quote(strconv.FormatInt(record.Seed, 10)))
+
+	for _, name := range record.Players {
+		fmt.Fprintf(&text, "[Player %s]\n", strconv.Quote(name))
+	}
+
+	rules, err := formatRules(record.Rules)
+	if err != nil {
+		return "", err
+	}
+
+	keys := []string{}
+	for key := range rules {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(&text, "[%s %s]\n", key, strconv.Quote(rules[key]))
+	}
+
+	game := NewRecordGame(record)
+	tokens := []string{}
+
+	for _, action := range record.Actions {
+		token, err := formatMove(game, action)
+		if err != nil {
+			return "", err
+		}
+
+		game, _, err = Apply(game, action)
+		if err != nil {
+			return "", &GameError{message: fmt.Sprintf("Can't play %s: %s", token, err)}
+		}
+
+		// Start each round on a new line
+		if (action.Kind == StartAction || action.Kind == NextRoundAction) && len(tokens) > 0 {
+			fmt.Fprintf(&text, "\n%s", strings.Join(tokens, " "))
+			tokens = []string{}
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	fmt.Fprintf(&text, "\n%s\n", strings.Join(tokens, " "))
+
+	return text.String(), nil
+}
+
+// formatRules returns each rule keyed by its setting name, in the form UpdateRuleSet accepts
+func formatRules(rules RuleSet) (map[string]string, error) {
+	stored, _ := json.Marshal(rules)
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(stored, &values); err != nil {
+		return nil, err
+	}
+
+	settings := map[string]string{}
+	for key, value := range values {
+		settings[key] = fmt.Sprint(value)
+	}
+
+	return settings, nil
+}
+
+// formatName quotes a player's name if it can't be written as it is
+func formatName(name string) string {
+	if name == "" || strings.ContainsAny(name, " \t\n\":/,[]") {
+		return strconv.Quote(name)
+	}
+
+	return name
+}
+
+// formatMove writes the move a player makes with an action, in the game before the action is applied
+func formatMove(game *Game, action Action) (string, error) {
+	switch action.Kind {
+	case StartAction, NextRoundAction:
+		return string(action.Kind), nil
+	}
+
+	if action.Player < 0 || action.Player >= len(game.Players) {
+		return "", &GameError{message: "Invalid player"}
+	}
+
+	player := game.Players[action.Player]
+	move := ""
+
+	switch action.Kind {
+	case PlayAction, JumpInAction:
+		names := []string{}
+		wild := false
+
+		for _, cardIndex := range action.Cards {
+			if cardIndex < 0 || cardIndex >= len(player.Cards) {
+				return "", &GameError{message: "Invalid card index"}
+			}
+
+			names = append(names, player.Cards[cardIndex].String())
+			wild = wild || player.Cards[cardIndex].IsWild()
+		}
+
+		move = strings.Join(names, ",")
+
+		if action.Kind == JumpInAction {
+			move = "jump/" + move
+		}
+
+		if wild && action.Color != NoColor {
+			move += "/" + string(action.Color)
+		}
+
+	case DrawAction:
+		move = "draw"
+
+	case PassAction:
+		move = "pass"
+
+	case ChooseColorAction:
+		move = "color/" + string(action.Color)
+
+	case SwapHandsAction, CatchUnoAction:
+		if action.Target < 0 || action.Target >= len(game.Players) {
+			return "", &GameError{message: "Invalid target player"}
+		}
+
+		move = "swap/"
+		if action.Kind == CatchUnoAction {
+			move = "catch/"
+		}
+
+		move += formatName(game.Players[action.Target].Name)
+
+	case CallUnoAction:
+		move = "uno"
+
+	case ChallengeAction:
+		move = "challenge"
+
+	default:
+		return "", &GameError{message: "Unknown action: " + string(action.Kind)}
+	}
+
+	return formatName(player.Name) + ":" + move, nil
+}
+
+// ParseGame reads a game written in the notation, and plays it through the engine. It returns the game as
+// it stands after the last move, along with the record that was read.
+func ParseGame(text string) (*Game, *GameRecord, error) {
+	record := &GameRecord{Rules: DefaultRuleSet()}
+	settings := map[string]string{}
+
+	lines := strings.Split(text, "\n")
+	moves := []string{}
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+
+		if !strings.HasPrefix(line, "[") {
+			moves = append(moves, line)
+			continue
+		}
+
+		key, value, err := parseTag(line)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch key {
+		case "Seed":
+			record.Seed, err = strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, nil, &GameError{message: "Expected the seed to be a number"}
+			}
+
+		case "Player":
+			record.Players = append(record.Players, value)
+
+		default:
+			settings[key] = value
+		}
+	}
+
+	rules, err := UpdateRuleSet(record.Rules, settings)
+	if err != nil {
+		return nil, nil, err
+	}
+	record.Rules = rules
+
+	tokens, err := splitTokens(strings.Join(moves, " "))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	game := NewRecordGame(record)
+
+	for _, token := range tokens {
+		action, err := parseMove(game, token)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		game, _, err = Apply(game, action)
+		if err != nil {
+			return nil, nil, &GameError{message: fmt.Sprintf("Can't play %s: %s", token, err)}
+		}
+
+		record.Actions = append(record.Actions, action)
+	}
+
+	return game, record, nil
+}
+
+// parseTag reads a header tag, like [Player "Nia"]
+func parseTag(line string) (string, string, error) {
+	if !strings.HasSuffix(line, "]") {
+		return "", "", &GameError{message: "Unterminated tag: " + line}
+	}
+
+	parts := strings.SplitN(strings.TrimSpace(line[1:len(line)-1]), " ", 2)
+	if len(parts) != 2 {
+		return "", "", &GameError{message: "Expected a tag name and value: " + line}
+	}
+
+	value, err := strconv.Unquote(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return "", "", &GameError{message: "Expected the tag value to be quoted: " + line}
+	}
+
+	return parts[0], value, nil
+}
+
+// splitTokens splits the moves on whitespace, keeping quoted names together
+func splitTokens(text string) ([]string, error) {
+	tokens := []string{}
+	token := strings.Builder{}
+	quoted := false
+	escaped := false
+
+	for _, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+
+		case quoted && r == '\\':
+			escaped = true
+
+		case r == '"':
+			quoted = !quoted
+
+		case !quoted && (r == ' ' || r == '\t' || r == '\r'):
+			if token.Len() > 0 {
+				tokens = append(tokens, token.String())
+				token.Reset()
+			}
+			continue
+		}
+
+		token.WriteRune(r)
+	}
+
+	if quoted {
+		return nil, &GameError{message: "Unterminated quote in moves"}
+	}
+
+	if token.Len() > 0 {
+		tokens = append(tokens, token.String())
+	}
+
+	return tokens, nil
+}
+
+// readName reads a player's name from the start of the text, which is either quoted or runs up to the
+// separator. It returns the name and the rest of the text after it.
+func readName(text string, separator string) (string, string, error) {
+	if !strings.HasPrefix(text, "\"") {
+		end := strings.Index(text, separator)
+		if end == -1 {
+			end = len(text)
+		}
+
+		return text[:end], text[end:], nil
+	}
+
+	for end := 1; end < len(text); end++ {
+		if text[end] == '\\' {
+			end++
+			continue
+		}
+
+		if text[end] == '"' {
+			name, err := strconv.Unquote(text[:end+1])
+			return name, text[end+1:], err
+		}
+	}
+
+	return "", "", &GameError{message: "Unterminated quote: " + text}
+}
+
+// playerByName returns the index of the named player
+func playerByName(game *Game, name string) (int, error) {
+	playerIndex := GetPlayerIndex(game, name)
+	if playerIndex == -1 {
+		return -1, &GameError{message: "Unknown player: " + name}
+	}
+
+	return playerIndex, nil
+}
+
+// parseMove reads a single move, and returns the action it makes in the game as it stands
+func parseMove(game *Game, token string) (Action, error) {
+	switch ActionKind(token) {
+	case StartAction, NextRoundAction:
+		return Action{Kind: ActionKind(token), Player: 0}, nil
+	}
+
+	name, rest, err := readName(token, ":")
+	if err != nil {
+		return Action{}, err
+	}
+
+	if !strings.HasPrefix(rest, ":") {
+		return Action{}, &GameError{message: "Expected a player and a move: " + token}
+	}
+
+	playerIndex, err := playerByName(game, name)
+	if err != nil {
+		return Action{}, err
+	}
+
+	action := Action{Player: playerIndex}
+	move := rest[1:]
+
+	switch move {
+	case "draw":
+		action.Kind = DrawAction
+
+	case "pass":
+		action.Kind = PassAction
+
+	case "uno":
+		action.Kind = CallUnoAction
+
+	case "challenge":
+		action.Kind = ChallengeAction
+
+	default:
+		return parseMoveWithArgument(game, action, move)
+	}
+
+	return action, nil
+}
+
+// parseMoveWithArgument reads the moves that play cards, or that name a color or another player
+func parseMoveWithArgument(game *Game, action Action, move string) (Action, error) {
+	parts := strings.SplitN(move, "/", 2)
+
+	switch parts[0] {
+	case "color":
+		if len(parts) != 2 {
+			return action, &GameError{message: "Expected a color: " + move}
+		}
+
+		action.Kind = ChooseColorAction
+		action.Color = Color(parts[1])
+
+		return action, nil
+
+	case "swap", "catch":
+		if len(parts) != 2 {
+			return action, &GameError{message: "Expected a player: " + move}
+		}
+
+		name, _, err := readName(parts[1], "/")
+		if err != nil {
+			return action, err
+		}
+
+		action.Kind = SwapHandsAction
+		if parts[0] == "catch" {
+			action.Kind = CatchUnoAction
+		}
+
+		action.Target, err = playerByName(game, name)
+		return action, err
+
+	case "jump":
+		if len(parts) != 2 {
+			return action, &GameError{message: "Expected a card: " + move}
+		}
+
+		action.Kind = JumpInAction
+		action.DiscardPileCount = len(game.DiscardPile)
+		parts = strings.SplitN(parts[1], "/", 2)
+
+	default:
+		action.Kind = PlayAction
+	}
+
+	names := strings.Split(parts[0], ",")
+	used := map[int]bool{}
+
+	for _, name := range names {
+		card, err := ParseCard(name)
+		if err != nil {
+			return action, err
+		}
+
+		cardIndex := -1
+		for i, held := range game.Players[action.Player].Cards {
+			if held == card && !used[i] {
+				cardIndex = i
+				break
+			}
+		}
+
+		if cardIndex == -1 {
+			return action, &GameError{message: fmt.Sprintf("%s doesn't hold %s", game.Players[action.Player].Name, name)}
+		}
+
+		used[cardIndex] = true
+		action.Cards = append(action.Cards, cardIndex)
+	}
+
+	if len(parts) == 2 {
+		action.Color = Color(parts[1])
+	}
+
+	return action, nil
+}
+
+// RecordFromReplay builds a game record from a game's replay log. The replay has to include the seed,
+// which it only does once the game is over.
+func RecordFromReplay(replay *Replay) (*GameRecord, error) {
+	if replay.Seed == nil {
+		return nil, &GameError{message: "The game has to be over before it can be written down"}
+	}
+
+	record := &GameRecord{
+		Seed:    *replay.Seed,
+		Players: []string{},
+		Rules:   DefaultRuleSet(),
+		Actions: []Action{},
+	}
+
+	for _, entry := range replay.Log {
+		if entry.Action != nil {
+			record.Actions = append(record.Actions, *entry.Action)
+			continue
+		}
+
+		switch entry.Verb {
+		case "createGame", "joinGame", "addBot":
+			record.Players = append(record.Players, entry.Player)
+
+		case "leaveGame", "kickPlayer", "banPlayer":
+			if len(record.Actions) > 0 {
+				return nil, &GameError{message: "Games where a player left part way through can't be written down"}
+			}
+
+			// Players who were kicked are named in the command, rather than being the one who sent it
+			leaving := entry.Player
+			if entry.Verb != "leaveGame" {
+				leaving = entry.Data["playerName"]
+			}
+
+			for i, name := range record.Players {
+				if name == leaving {
+					record.Players = append(record.Players[:i], record.Players[i+1:]...)
+					break
+				}
+			}
+
+		case "updateSettings":
+			rules, err := UpdateRuleSet(record.Rules, entry.Data)
+			if err != nil {
+				return nil, err
+			}
+
+			record.Rules = rules
+
+		case "restartGame":
+			return nil, &GameError{message: "Games that were restarted can't be written down"}
+		}
+	}
+
+	return record, nil
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// How often the seats of disconnected players are checked on
+const presenceCheckInterval = time.Second
+
+// The set of games being played with disconnected players in them. It's kept in Redis, so the seats
+// are still held for them after the server restarts.
+const disconnectedGamesKey = "games:disconnected"
+
+// Taking a turn for someone can't go on forever, even when drawing until a playable card turns up
+const maxTimeoutMoves = 200
+
+// SetPlayerConnected returns a game with the player marked as connected or disconnected. Players who
+// aren't at the table are ignored. A player who comes back to a game without a host takes over hosting.
+func SetPlayerConnected(game *Game, playerName string, connected bool, now time.Time) *Game {
+	playerIndex := GetPlayerIndex(game, playerName)
+	if playerIndex == -1 {
+		return game
+	}
+
+	if connected && game.Host == "" && game.Players[playerIndex].Bot == NoBot {
+		game.Host = playerName
+	}
+
+	game.Players[playerIndex].Disconnected = !connected
+	game.Players[playerIndex].DisconnectedAt = time.Time{}
+
+	if !connected {
+		game.Players[playerIndex].DisconnectedAt = now
+	}
+
+	return game
+}
+
+// waitingOn returns true if the game can't carry on until the player makes a move
+func waitingOn(game *Game, playerIndex int) bool {
+	if game.State != GamePlaying {
+		return false
+	}
+
+	if game.PendingChoice.Kind != NoChoice {
+		return game.PendingChoice.Player == playerIndex
+	}
+
+	return game.ActivePlayer == playerIndex
+}
+
+// TimeOutTurn returns the game after the player's turn has been taken for them, along with the moves
+// made. They draw and pass, making any choice the game is waiting on in the simplest way.
+func TimeOutTurn(game *Game, playerIndex int) (*Game, []BotMove) {
+	moves := []BotMove{}
+
+	for len(moves) < maxTimeoutMoves && waitingOn(game, playerIndex) {
+		legal := GetLegalMoves(game, playerIndex)
+		action := Action{Player: playerIndex}
+
+		switch {
+		case len(legal.SwapTargets) > 0:
+			action.Kind = SwapHandsAction
+			action.Target = legal.SwapTargets[0]
+
+		case game.PendingChoice.Kind == StartingColorChoice && len(legal.WildColors) > 0:
+			action.Kind = ChooseColorAction
+			action.Color = legal.WildColors[0]
+
+		case legal.CanDoneDrawing:
+			action.Kind = PassAction
+
+		case legal.CanDraw:
+			action.Kind = DrawAction
+
+		// Some rules make a player play the card they drew
+		case len(legal.PlayableCards) > 0:
+			action.Kind = PlayAction
+			action.Cards = legal.PlayableCards[:1]
+
+			if game.Players[playerIndex].Cards[action.Cards[0]].IsWild() {
+				action.Color = colors[0]
+			}
+
+		default:
+			return game, moves
+		}
+
+		next, _, err := Apply(game, action)
+		if err != nil {
+			log.Printf("Unable to take %s's turn: %s", game.Players[playerIndex].Name, err)
+			return game, moves
+		}
+
+		game = next
+		moves = append(moves, BotMove{Action: action, Game: game})
+	}
+
+	return game, moves
+}
+
+// PlayerDisconnected marks the session's player as disconnected from their game, and holds their seat
+// until they come back or the grace period runs out
+func PlayerDisconnected(ctx *context.Context, rdb *redis.Client, persistentSession *PersistentSession) {
+	gameId := persistentSession.ActiveGame
+	playerName := persistentSession.PlayerName
+
+	if gameId == "" || persistentSession.Spectating {
+		return
+	}
+
+	now := time.Now().UTC()
+
+	game, err := UpdateGame(ctx, rdb, gameId, func(game *Game) (*Game, error) {
+		if GetPlayerIndex(game, playerName) == -1 || game.State == GameAbandoned {
+			return nil, errors.New("Player isn't in the game")
+		}
+
+		return SetPlayerConnected(game, playerName, false, now), nil
+	})
+	if err != nil {
+		return
+	}
+
+	watchDisconnectedPlayers(ctx, rdb, gameId, game)
+}
+
+// beingPlayed returns true if the game is in the middle of a match, where a disconnected player can
+// hold it up
+func beingPlayed(game *Game) bool {
+	return game.State == GamePlaying || game.State == RoundComplete
+}
+
+// watchDisconnectedPlayers has the game's disconnected players watched over, if it's being played
+func watchDisconnectedPlayers(ctx *context.Context, rdb *redis.Client, gameId string, game *Game) {
+	if !beingPlayed(game) {
+		return
+	}
+
+	for _, player := range game.Players {
+		if player.Disconnected {
+			rdb.SAdd(*ctx, disconnectedGamesKey, gameId)
+			return
+		}
+	}
+}
+
+// PlayerReconnected marks the session's player as connected to their game again
+func PlayerReconnected(ctx *context.Context, rdb *redis.Client, persistentSession *PersistentSession) {
+	gameId := persistentSession.ActiveGame
+	playerName := persistentSession.PlayerName
+
+	UpdateGame(ctx, rdb, gameId, func(game *Game) (*Game, error) {
+		playerIndex := GetPlayerIndex(game, playerName)
+		if playerIndex == -1 || !game.Players[playerIndex].Disconnected {
+			return nil, errors.New("Player isn't disconnected")
+		}
+
+		return SetPlayerConnected(game, playerName, true, time.Time{}), nil
+	})
+}
+
+// WatchDisconnectedPlayers holds the seats of disconnected players in every game being played. Their
+// turns are taken for them once they time out, and once the grace period is over their turns are either
+// taken straight away or they're removed from the game, depending on the rules. A game stops being
+// watched once nobody in it is disconnected, or it's no longer being played.
+func WatchDisconnectedPlayers(ctx *context.Context, rdb *redis.Client) {
+	ticker := time.NewTicker(presenceCheckInterval)
+	defer ticker.Stop()
+
+	// When each game started waiting on each of its disconnected players. This starts over after a
+	// restart, which only gives the players a little longer.
+	waitingSince := map[string]map[string]time.Time{}
+
+	for range ticker.C {
+		gameIds, err := rdb.SMembers(*ctx, disconnectedGamesKey).Result()
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		watched := map[string]map[string]time.Time{}
+
+		for _, gameId := range gameIds {
+			waiting := checkDisconnectedPlayers(ctx, rdb, gameId, waitingSince[gameId])
+			if waiting == nil {
+				rdb.SRem(*ctx, disconnectedGamesKey, gameId)
+				continue
+			}
+
+			watched[gameId] = waiting
+		}
+
+		waitingSince = watched
+	}
+}
+
+// checkDisconnectedPlayers checks on each disconnected player in the game, and returns when the game
+// started waiting on each of them. It returns nil once the game no longer needs watching.
+func checkDisconnectedPlayers(ctx *context.Context, rdb *redis.Client, gameId string, waitingSince map[string]time.Time) map[string]time.Time {
+	game, err := LoadGame(ctx, rdb, gameId)
+	if err != nil || !beingPlayed(game) {
+		return nil
+	}
+
+	var waiting map[string]time.Time
+
+	for playerIndex, player := range game.Players {
+		if !player.Disconnected {
+			continue
+		}
+
+		if waiting == nil {
+			waiting = map[string]time.Time{}
+		}
+
+		waiting[player.Name] = checkDisconnectedPlayer(ctx, rdb, gameId, game, playerIndex, waitingSince[player.Name])
+	}
+
+	return waiting
+}
+
+// checkDisconnectedPlayer takes a disconnected player's turn if it has timed out, or removes them once
+// the grace period is over if the rules say so. It returns when the game started waiting on them, or
+// the zero time if it isn't waiting on them.
+func checkDisconnectedPlayer(ctx *context.Context, rdb *redis.Client, gameId string, game *Game, playerIndex int, waitingSince time.Time) time.Time {
+	player := game.Players[playerIndex]
+	since := player.DisconnectedAt
+
+	now := time.Now().UTC()
+	graceOver := now.Sub(since) >= time.Duration(game.Rules.GracePeriod)*time.Second
+
+	if graceOver && game.Rules.AfterGracePeriod == DisconnectRemove {
+		removeDisconnectedPlayer(ctx, rdb, gameId, player.Name, since)
+		return time.Time{}
+	}
+
+	if graceOver && game.Host == player.Name {
+		handOffDisconnectedHost(ctx, rdb, gameId, player.Name, since)
+	}
+
+	if !waitingOn(game, playerIndex) {
+		return time.Time{}
+	}
+
+	if waitingSince.IsZero() {
+		waitingSince = now
+	}
+
+	if graceOver || now.Sub(waitingSince) >= time.Duration(game.Rules.TurnTimeout)*time.Second {
+		timeOutTurn(ctx, rdb, gameId, player.Name, since)
+		return time.Time{}
+	}
+
+	return waitingSince
+}
+
+// stillDisconnected returns the index of the player if they're still disconnected from the time given,
+// or -1 if they're back or have left
+func stillDisconnected(game *Game, playerName string, since time.Time) int {
+	playerIndex := GetPlayerIndex(game, playerName)
+	if playerIndex == -1 || !game.Players[playerIndex].Disconnected || !game.Players[playerIndex].DisconnectedAt.Equal(since) {
+		return -1
+	}
+
+	return playerIndex
+}
+
+// timeOutTurn takes the disconnected player's turn for them, and lets any bots take theirs after
+func timeOutTurn(ctx *context.Context, rdb *redis.Client, gameId string, playerName string, since time.Time) {
+	var moves []BotMove
+
+	_, err := UpdateGame(ctx, rdb, gameId, func(game *Game) (*Game, error) {
+		// The player may have come back since the game was checked
+		playerIndex := stillDisconnected(game, playerName, since)
+		if playerIndex == -1 || !waitingOn(game, playerIndex) {
+			return nil, errors.New("The game isn't waiting on the player")
+		}
+
+		game, moves = TimeOutTurn(game, playerIndex)
+
+		game, botMoves := RunBots(game)
+		moves = append(moves, botMoves...)
+
+		return gatherEvents(game, nil, moves), nil
+	})
+	if err != nil {
+		return
+	}
+
+	recordBotMoves(ctx, rdb, gameId, moves)
+}
+
+// removeDisconnectedPlayer removes a player who didn't come back before the grace period ran out
+func removeDisconnectedPlayer(ctx *context.Context, rdb *redis.Client, gameId string, playerName string, since time.Time) {
+	var left *Game
+	var moves []BotMove
+
+	_, err := UpdateGame(ctx, rdb, gameId, func(game *Game) (*Game, error) {
+		if stillDisconnected(game, playerName, since) == -1 {
+			return nil, errors.New("The player is back")
+		}
+
+		var updated *Game
+		left, updated, moves = removeFromGame(game, playerName)
+
+		return updated, nil
+	})
+	if err != nil {
+		return
+	}
+
+	log.Printf("Removed %s from %s after they were disconnected", playerName, gameId)
+
+	RecordCommand(ctx, rdb, gameId, playerName, &Command{Verb: "leaveGame"}, left, nil)
+	recordBotMoves(ctx, rdb, gameId, moves)
+}
+
+// handOffDisconnectedHost passes hosting on from a host who didn't come back before the grace period ran
+// out, so the game isn't left without anyone to start or end it
+func handOffDisconnectedHost(ctx *context.Context, rdb *redis.Client, gameId string, playerName string, since time.Time) {
+	UpdateGame(ctx, rdb, gameId, func(game *Game) (*Game, error) {
+		playerIndex := stillDisconnected(game, playerName, since)
+		if playerIndex == -1 || game.Host != playerName {
+			return nil, errors.New("The player isn't a disconnected host")
+		}
+
+		game = handOffHost(game, playerIndex)
+
+		// With nobody else connected, they stay the host
+		hostIndex := GetPlayerIndex(game, game.Host)
+		if hostIndex == -1 || game.Players[hostIndex].Disconnected {
+			return nil, errors.New("There's nobody to hand hosting to")
+		}
+
+		return game, nil
+	})
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Replay logs are kept well after the game itself expires, so finished games can still be looked back on
+const replayExpiry = 7 * 24 * time.Hour
+
+// Deal is the cards dealt at the start of a round
+type Deal struct {
+	Hands       [][]Card `json:"hands"`
+	DrawPile    []Card   `json:"drawPile"`
+	DiscardPile []Card   `json:"discardPile"`
+}
+
+// ReplayEntry is a single accepted command in a game's replay log
+type ReplayEntry struct {
+	Player    string            `json:"player"`
+	Verb      string            `json:"verb"`
+	Data      map[string]string `json:"data"`
+	Timestamp time.Time         `json:"timestamp"`
+	TopCard   Card              `json:"topCard"`
+
+	// For commands that were applied to the game as an action, the action and the events it caused
+	Action *Action `json:"action,omitempty"`
+	Events []Event `json:"events,omitempty"`
+
+	// For commands that dealt a round, the cards that were dealt
+	Deal *Deal `json:"deal,omitempty"`
+}
+
+// Replay is the full history of a game. The seed, the deals and the cards drawn give away every player's
+// hand, so they're only included once the game is over.
+type Replay struct {
+	GameId   string        `json:"gameId"`
+	Complete bool          `json:"complete"`
+	Seed     *int64        `json:"seed"`
+	Log      []ReplayEntry `json:"log"`
+}
+
+func replayLogKey(gameId string) string {
+	return "game:" + gameId + ":log"
+}
+
+func replaySeedKey(gameId string) string {
+	return "game:" + gameId + ":seed"
+}
+
+// StartReplay clears out the replay log for a new game, and records the seed it was created with. Game
+// codes are reused, so there may be a log left over from an older game.
+func StartReplay(ctx *context.Context, rdb *redis.Client, gameId string, seed int64) {
+	_, err := rdb.TxPipelined(*ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(*ctx, replayLogKey(gameId))
+		pipe.Set(*ctx, replaySeedKey(gameId), seed, replayExpiry)
+		return nil
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// RecordCommand appends a command that was accepted to the game's replay log, along with the action it
+// was applied as, if any
+func RecordCommand(ctx *context.Context, rdb *redis.Client, gameId string, playerName string, cmd *Command, game *Game, action *Action) {
+	entry := ReplayEntry{
+		Player:    playerName,
+		Verb:      cmd.Verb,
+		Data:      cmd.Data,
+		Timestamp: time.Now().UTC(),
+	}
+
+	if len(game.DiscardPile) > 0 {
+		entry.TopCard = game.DiscardPile[0]
+	}
+
+	if action != nil {
+		entry.Action = action
+		entry.Events = game.Events
+	}
+
+	// The hands are untouched until the first player takes their turn, so they're still as dealt
+	if action != nil && (action.Kind == StartAction || action.Kind == NextRoundAction) {
+		entry.Deal = &Deal{
+			Hands:       [][]Card{},
+			DrawPile:    game.DrawPile,
+			DiscardPile: game.DiscardPile,
+		}
+
+		for _, player := range game.Players {
+			entry.Deal.Hands = append(entry.Deal.Hands, player.Cards)
+		}
+	}
+
+	stored, _ := json.Marshal(entry)
+
+	_, err := rdb.TxPipelined(*ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(*ctx, replayLogKey(gameId), stored)
+		pipe.Expire(*ctx, replayLogKey(gameId), replayExpiry)
+		pipe.Expire(*ctx, replaySeedKey(gameId), replayExpiry)
+		return nil
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// LoadReplay returns the replay of a game. While the game is still being played, anything that would
+// give away the players' hands is left out.
+func LoadReplay(ctx *context.Context, rdb *redis.Client, gameId string) (*Replay, error) {
+	stored, err := rdb.LRange(*ctx, replayLogKey(gameId), 0, -1).Result()
+	if err != nil || len(stored) == 0 {
+		return nil, errors.New("There's no replay for that game")
+	}
+
+	replay := Replay{
+		GameId: gameId,
+		Log:    []ReplayEntry{},
+	}
+
+	for _, line := range stored {
+		var entry ReplayEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, errors.New("Unable to unmarshal replay")
+		}
+
+		replay.Log = append(replay.Log, entry)
+	}
+
+	// A match that has been won can still be played again with the same deck, so the cards are only
+	// shown once the game has been ended or has expired
+	game, err := LoadGame(ctx, rdb, gameId)
+	replay.Complete = err != nil || game.State == GameAbandoned
+
+	if !replay.Complete {
+		return hideReplayCards(&replay), nil
+	}
+
+	seed, err := rdb.Get(*ctx, replaySeedKey(gameId)).Result()
+	if err == nil {
+		if parsed, err := strconv.ParseInt(seed, 10, 64); err == nil {
+			replay.Seed = &parsed
+		}
+	}
+
+	return &replay, nil
+}
+
+// hideReplayCards removes the deals and the cards drawn from a replay
+func hideReplayCards(replay *Replay) *Replay {
+	for i, entry := range replay.Log {
+		replay.Log[i].Deal = nil
+
+		for j, event := range entry.Events {
+			if event.Kind == CardDrawnEvent {
+				replay.Log[i].Events[j].Card = Card{}
+			}
+		}
+	}
+
+	return replay
+}
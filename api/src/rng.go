@@ -0,0 +1,43 @@
+package main
+
+import "math/rand"
+
+// Rng is the random number generator a game shuffles and deals with. Its whole state is a single number
+// that is stored with the game, so a game can be reproduced exactly from its seed and the actions taken
+// in it. It implements the splitmix64 generator, and can be used as a math/rand source.
+type Rng struct {
+	State uint64 `json:"state"`
+}
+
+// NewRng returns a generator seeded with the given seed
+func NewRng(seed int64) Rng {
+	return Rng{State: uint64(seed)}
+}
+
+func (r *Rng) Seed(seed int64) {
+	r.State = uint64(seed)
+}
+
+func (r *Rng) Uint64() uint64 {
+	r.State += 0x9e3779b97f4a7c15
+
+	z := r.State
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+
+	return z ^ (z >> 31)
+}
+
+func (r *Rng) Int63() int64 {
+	return int64(r.Uint64() >> 1)
+}
+
+// random returns a math/rand generator drawing from the game's own generator
+func random(game *Game) *rand.Rand {
+	return rand.New(&game.Rng)
+}
+
+// botRandom returns a math/rand generator drawing from the generator the game's bots use
+func botRandom(game *Game) *rand.Rand {
+	return rand.New(&game.BotRng)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type DrawMode string
+
+const (
+	// Draw a single card, then either play or pass
+	DrawSingle DrawMode = "single"
+
+	// Keep drawing until a playable card turns up
+	DrawUntilPlayable DrawMode = "untilPlayable"
+)
+
+type DisconnectMode string
+
+const (
+	// Keep the seat for the player, taking each of their turns for them once it times out
+	DisconnectSkip DisconnectMode = "skip"
+
+	// Remove the player from the game
+	DisconnectRemove DisconnectMode = "remove"
+)
+
+// RuleSet holds the house rules a game is played with. The host picks them in the lobby, and every
+// engine function reads them from the Game rather than assuming a fixed set of rules.
+type RuleSet struct {
+	// Number of cards dealt to each player at the start of a round
+	HandSize int `json:"handSize"`
+
+	// With only two players, a reverse acts like a skip
+	TwoPlayerReverseSkip bool `json:"twoPlayerReverseSkip"`
+
+	// A player facing a draw penalty may pass it on by playing a +2 on a +2
+	StackDrawTwo bool `json:"stackDrawTwo"`
+
+	// A player facing a draw penalty may pass it on by playing a wild+4 on a +2 or wild+4
+	StackDrawFour bool `json:"stackDrawFour"`
+
+	// Any player holding a card identical to the top of the discard pile may play it out of turn
+	JumpIn bool `json:"jumpIn"`
+
+	// Playing a 7 swaps hands with a chosen player, and playing a 0 passes every hand along
+	SevenO bool `json:"sevenO"`
+
+	// Number of cards a player draws when they're caught not calling UNO
+	UnoPenalty int `json:"unoPenalty"`
+
+	// The victim of a wild+4 may challenge it if they think the player held a card of the matching color
+	WildDrawFourChallenge bool `json:"wildDrawFourChallenge"`
+
+	// Rounds are played until a player's score reaches the target. A target of 0 plays a single round.
+	TargetScore int `json:"targetScore"`
+
+	// How many cards a player draws on their turn when they don't (or can't) play
+	DrawMode DrawMode `json:"drawMode"`
+
+	// After drawing, the player may only play the card they drew
+	PlayDrawnOnly bool `json:"playDrawnOnly"`
+
+	// If the card a player draws is playable, they have to play it
+	MustPlayDrawn bool `json:"mustPlayDrawn"`
+
+	// Several number cards with the same number can be played together in one turn
+	MultiPlay bool `json:"multiPlay"`
+
+	// Four players play two-versus-two, with partners sitting opposite each other
+	Teams bool `json:"teams"`
+
+	// At the end of each round the player holding the most points is knocked out, until one is left
+	Elimination bool `json:"elimination"`
+
+	// A wild+4 turned over to start the discard pile goes back into the deck, which is reshuffled
+	ReshuffleStartingWildFour bool `json:"reshuffleStartingWildFour"`
+
+	// The first player chooses the color of a wild turned over to start the discard pile. Otherwise a
+	// color is picked at random.
+	ChooseStartingColor bool `json:"chooseStartingColor"`
+
+	// A skip, reverse or +2 turned over to start the discard pile applies to the first player
+	ApplyStartingAction bool `json:"applyStartingAction"`
+
+	// Players can ask for a suggested move with getHint
+	AllowHints bool `json:"allowHints"`
+
+	// How many seconds a disconnected player's seat is held for, and how many seconds they get to take
+	// each turn while they're disconnected before it's taken for them
+	GracePeriod int `json:"gracePeriod"`
+	TurnTimeout int `json:"turnTimeout"`
+
+	// What happens to a player who is still disconnected once the grace period is over
+	AfterGracePeriod DisconnectMode `json:"afterGracePeriod"`
+}
+
+// DefaultRuleSet returns the rules a new game starts with
+func DefaultRuleSet() RuleSet {
+	return RuleSet{
+		HandSize:             7,
+		TwoPlayerReverseSkip: true,
+		StackDrawTwo:         false,
+		StackDrawFour:        false,
+		JumpIn:               false,
+		SevenO:               false,
+		UnoPenalty:           2,
+
+		WildDrawFourChallenge: true,
+		TargetScore:           0,
+		DrawMode:              DrawSingle,
+		PlayDrawnOnly:         false,
+		MustPlayDrawn:         false,
+		MultiPlay:             false,
+		Teams:                 false,
+		Elimination:           false,
+
+		ReshuffleStartingWildFour: true,
+		ChooseStartingColor:       false,
+		ApplyStartingAction:       true,
+		AllowHints:                true,
+
+		GracePeriod:      120,
+		TurnTimeout:      30,
+		AfterGracePeriod: DisconnectSkip,
+	}
+}
+
+func parseBoolSetting(key string, value string) (bool, error) {
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, &GameError{message: fmt.Sprintf("Expected %s to be true or false", key)}
+	}
+
+	return parsed, nil
+}
+
+func parseIntSetting(key string, value string, min int, max int) (int, error) {
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < min || parsed > max {
+		return 0, &GameError{message: fmt.Sprintf("Expected %s to be a number between %d and %d", key, min, max)}
+	}
+
+	return parsed, nil
+}
+
+func parseDrawMode(key string, value string) (DrawMode, error) {
+	mode := DrawMode(value)
+	if mode != DrawSingle && mode != DrawUntilPlayable {
+		return DrawSingle, &GameError{message: fmt.Sprintf("Expected %s to be %s or %s", key, DrawSingle, DrawUntilPlayable)}
+	}
+
+	return mode, nil
+}
+
+func parseDisconnectMode(key string, value string) (DisconnectMode, error) {
+	mode := DisconnectMode(value)
+	if mode != DisconnectSkip && mode != DisconnectRemove {
+		return DisconnectSkip, &GameError{message: fmt.Sprintf("Expected %s to be %s or %s", key, DisconnectSkip, DisconnectRemove)}
+	}
+
+	return mode, nil
+}
+
+// UpdateRuleSet returns a copy of the rules with the given settings applied. Settings are keyed by the
+// JSON name of the rule, with values encoded as strings.
+func UpdateRuleSet(rules RuleSet, settings map[string]string) (RuleSet, error) {
+	var err error
+
+	for key, value := range settings {
+		switch key {
+		case "handSize":
+			rules.HandSize, err = parseIntSetting(key, value, 1, 20)
+
+		case "twoPlayerReverseSkip":
+			rules.TwoPlayerReverseSkip, err = parseBoolSetting(key, value)
+
+		case "stackDrawTwo":
+			rules.StackDrawTwo, err = parseBoolSetting(key, value)
+
+		case "stackDrawFour":
+			rules.StackDrawFour, err = parseBoolSetting(key, value)
+
+		case "jumpIn":
+			rules.JumpIn, err = parseBoolSetting(key, value)
+
+		case "sevenO":
+			rules.SevenO, err = parseBoolSetting(key, value)
+
+		case "unoPenalty":
+			rules.UnoPenalty, err = parseIntSetting(key, value, 0, 10)
+
+		case "wildDrawFourChallenge":
+			rules.WildDrawFourChallenge, err = parseBoolSetting(key, value)
+
+		case "targetScore":
+			rules.TargetScore, err = parseIntSetting(key, value, 0, 5000)
+
+		case "drawMode":
+			rules.DrawMode, err = parseDrawMode(key, value)
+
+		case "playDrawnOnly":
+			rules.PlayDrawnOnly, err = parseBoolSetting(key, value)
+
+		case "mustPlayDrawn":
+			rules.MustPlayDrawn, err = parseBoolSetting(key, value)
+
+		case "multiPlay":
+			rules.MultiPlay, err = parseBoolSetting(key, value)
+
+		case "teams":
+			rules.Teams, err = parseBoolSetting(key, value)
+
+		case "elimination":
+			rules.Elimination, err = parseBoolSetting(key, value)
+
+		case "reshuffleStartingWildFour":
+			rules.ReshuffleStartingWildFour, err = parseBoolSetting(key, value)
+
+		case "chooseStartingColor":
+			rules.ChooseStartingColor, err = parseBoolSetting(key, value)
+
+		case "applyStartingAction":
+			rules.ApplyStartingAction, err = parseBoolSetting(key, value)
+
+		case "allowHints":
+			rules.AllowHints, err = parseBoolSetting(key, value)
+
+		case "gracePeriod":
+			rules.GracePeriod, err = parseIntSetting(key, value, 0, 3600)
+
+		case "turnTimeout":
+			rules.TurnTimeout, err = parseIntSetting(key, value, 5, 600)
+
+		case "afterGracePeriod":
+			rules.AfterGracePeriod, err = parseDisconnectMode(key, value)
+
+		default:
+			err = &GameError{message: fmt.Sprintf("Unknown setting: %s", key)}
+		}
+
+		if err != nil {
+			return rules, err
+		}
+	}
+
+	return rules, nil
+}
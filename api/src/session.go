@@ -16,9 +16,11 @@ import (
 type PersistentSession struct {
 	SessionId  string    `json:"sessionId"`
 	PlayerName string    `json:"playerName"`
-	GameHost   bool      `json:"gameHost"`
 	ActiveGame string    `json:"activeGame"`
 	UnsubChan  chan bool `json:"-"`
+
+	// Spectators watch the active game without playing in it
+	Spectating bool `json:"spectating"`
 }
 
 func NewSessionId() string {
@@ -32,7 +34,6 @@ func NewPersistentSession() *PersistentSession {
 	log.Printf("Creating new sessionId %s", sessionId)
 
 	return &PersistentSession{
-		GameHost:   false,
 		SessionId:  sessionId,
 		PlayerName: "",
 		ActiveGame: "",
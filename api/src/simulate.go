@@ -0,0 +1,379 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// The most rounds a simulated match plays before it's given up on
+const maxSimulatedRounds = 100
+
+// SimulationResult sums up a batch of simulated games, each played as a whole match
+type SimulationResult struct {
+	Games    int
+	Finished int
+
+	// Matches won by each seat, and by whoever played first
+	SeatWins        []int
+	FirstPlayerWins int
+
+	// Moves made and turns taken, over every finished game
+	Moves int
+	Turns int
+
+	// Times the draw pile ran out and was made up again from the discard pile, and the number of games
+	// where it happened at least once
+	Reshuffles      int
+	GamesReshuffled int
+}
+
+// Simulate plays a match between bots of the given difficulties, once for each of the seeds starting at
+// seed. The games are played straight through the engine, so no server is needed.
+func Simulate(rules RuleSet, bots []BotDifficulty, games int, seed int64) (SimulationResult, error) {
+	result := SimulationResult{
+		Games:    games,
+		SeatWins: make([]int, len(bots)),
+	}
+
+	for i := 0; i < games; i++ {
+		game := EmptyGame("", "", seed+int64(i))
+		game.Rules = rules
+
+		for _, difficulty := range bots {
+			game, _ = AddBot(game, difficulty)
+		}
+
+		game, events, err := Apply(game, Action{Kind: StartAction, Player: 0})
+		if err != nil {
+			return result, err
+		}
+
+		first := game.ActivePlayer
+		active := first
+
+		game, moves := RunBots(game)
+
+		// Play on until the match is won, with each round's deal added to the moves made
+		for round := 1; game.State == RoundComplete && round < maxSimulatedRounds; round++ {
+			next, _, err := Apply(game, Action{Kind: NextRoundAction, Player: 0})
+			if err != nil {
+				return result, err
+			}
+
+			moves = append(moves, BotMove{Action: Action{Kind: NextRoundAction, Player: 0}, Game: next})
+
+			var roundMoves []BotMove
+			game, roundMoves = RunBots(next)
+			moves = append(moves, roundMoves...)
+		}
+
+		if game.State != GameComplete || game.MatchWinner < 0 {
+			continue
+		}
+
+		reshuffles := 0
+		for _, move := range moves {
+			events = append(events, move.Game.Events...)
+
+			if move.Game.ActivePlayer != active {
+				active = move.Game.ActivePlayer
+				result.Turns++
+			}
+		}
+
+		for _, event := range events {
+			if event.Kind == DrawPileReshuffledEvent {
+				reshuffles++
+			}
+		}
+
+		result.Finished++
+		result.SeatWins[game.MatchWinner]++
+		result.Moves += len(moves)
+		result.Reshuffles += reshuffles
+
+		if game.MatchWinner == first {
+			result.FirstPlayerWins++
+		}
+
+		if reshuffles > 0 {
+			result.GamesReshuffled++
+		}
+	}
+
+	return result, nil
+}
+
+// ruleVariant is a set of rules to simulate, named after how it differs from the rules it's compared to.
+// unexercised says why the bots wouldn't play any differently under it, if they wouldn't.
+type ruleVariant struct {
+	name        string
+	rules       RuleSet
+	unexercised string
+}
+
+// unexercised returns why the bots can't show the effect of switching a house rule, or "" if they can
+func unexercised(key string, rules RuleSet, bots []BotDifficulty) string {
+	has := func(difficulty BotDifficulty) bool {
+		for _, bot := range bots {
+			if bot == difficulty {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	switch key {
+	case "multiPlay":
+		return "bots only play one card at a time"
+
+	case "twoPlayerReverseSkip":
+		if len(bots) != 2 {
+			return "only applies with two players"
+		}
+
+	case "wildDrawFourChallenge":
+		if !has(HardBot) {
+			return "only hard bots challenge"
+		}
+
+	// Bots always play a card they drew if they can
+	case "mustPlayDrawn":
+		return "bots always play the card they drew if they can"
+
+	// Other bots never draw while they have a card to play, so the card they drew is the only one
+	// they could play
+	case "playDrawnOnly":
+		if !has(EasyBot) {
+			return "only easy bots draw instead of playing"
+		}
+
+	case "teams":
+		if len(bots) != 4 {
+			return "needs four players"
+		}
+
+		if rules.TargetScore == 0 {
+			return "only changes scoring, which needs a target score"
+		}
+	}
+
+	return ""
+}
+
+// ruleVariants returns the rules, followed by the rules with each of the house rules switched the other
+// way in turn
+func ruleVariants(rules RuleSet, bots []BotDifficulty) ([]ruleVariant, error) {
+	variants := []ruleVariant{{name: "baseline", rules: rules}}
+
+	settings, err := formatRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		// Hints don't change how bots play
+		if key == "allowHints" {
+			continue
+		}
+
+		value := ""
+
+		switch settings[key] {
+		case "true":
+			value = "false"
+		case "false":
+			value = "true"
+		case string(DrawSingle):
+			value = string(DrawUntilPlayable)
+		case string(DrawUntilPlayable):
+			value = string(DrawSingle)
+		default:
+			continue
+		}
+
+		changed, err := UpdateRuleSet(rules, map[string]string{key: value})
+		if err != nil {
+			return nil, err
+		}
+
+		variants = append(variants, ruleVariant{
+			name:        key + "=" + value,
+			rules:       changed,
+			unexercised: unexercised(key, rules, bots),
+		})
+	}
+
+	return variants, nil
+}
+
+// parseSettings reads settings written as a comma separated list of key=value pairs
+func parseSettings(text string) (map[string]string, error) {
+	settings := map[string]string{}
+
+	for _, setting := range strings.Split(text, ",") {
+		if strings.TrimSpace(setting) == "" {
+			continue
+		}
+
+		parts := strings.SplitN(setting, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Expected a setting like key=value, got %s", setting)
+		}
+
+		settings[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	return settings, nil
+}
+
+func percent(count int, total int) string {
+	if total == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.1f%%", 100*float64(count)/float64(total))
+}
+
+func average(count int, total int) string {
+	if total == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.1f", float64(count)/float64(total))
+}
+
+// runSimulation runs the simulate command, which plays bot games with a set of rules and reports how
+// they went, comparing the rules against each house rule being switched
+func runSimulation(args []string) error {
+	flags := flag.NewFlagSet("simulate", flag.ContinueOnError)
+
+	games := flags.Int("games", 1000, "number of games to play for each set of rules")
+	seed := flags.Int64("seed", 1, "seed for the first game, each game after uses the next seed")
+	bots := flags.String("bots", "normal,normal,normal,normal", "comma separated difficulty of the bot in each seat")
+	settings := flags.String("rules", "", "comma separated rules to play with, like sevenO=true,handSize=5")
+	compare := flags.Bool("compare", true, "also play with each house rule switched the other way")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	difficulties := []BotDifficulty{}
+	for _, name := range strings.Split(*bots, ",") {
+		difficulty, err := parseBotDifficulty(strings.TrimSpace(name))
+		if err != nil {
+			return err
+		}
+
+		difficulties = append(difficulties, difficulty)
+	}
+
+	parsed, err := parseSettings(*settings)
+	if err != nil {
+		return err
+	}
+
+	rules, err := UpdateRuleSet(DefaultRuleSet(), parsed)
+	if err != nil {
+		return err
+	}
+
+	variants := []ruleVariant{{name: "baseline", rules: rules}}
+	if *compare {
+		variants, err = ruleVariants(rules, difficulties)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Playing %d games for each set of rules, with bots: %s\n\n", *games, *bots)
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	header := "rules\tfinished\tmoves\tturns\treshuffles\treshuffled\tfirst player\t"
+	for i := range difficulties {
+		header += fmt.Sprintf("seat %d\t", i+1)
+	}
+	fmt.Fprintln(table, header)
+
+	// Each set of rules is simulated at the same time
+	results := make([]SimulationResult, len(variants))
+	errs := make([]error, len(variants))
+
+	var wg sync.WaitGroup
+	for i, variant := range variants {
+		if variant.unexercised != "" {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(i int, variant ruleVariant) {
+			defer wg.Done()
+			results[i], errs[i] = Simulate(variant.rules, difficulties, *games, *seed)
+		}(i, variant)
+	}
+	wg.Wait()
+
+	for i, variant := range variants {
+		if variant.unexercised != "" {
+			continue
+		}
+
+		result, err := results[i], errs[i]
+		if err != nil {
+			fmt.Fprintf(table, "%s\t%s\t\n", variant.name, err)
+			continue
+		}
+
+		row := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t",
+			variant.name,
+			percent(result.Finished, result.Games),
+			average(result.Moves, result.Finished),
+			average(result.Turns, result.Finished),
+			average(result.Reshuffles, result.Finished),
+			percent(result.GamesReshuffled, result.Finished),
+			percent(result.FirstPlayerWins, result.Finished),
+		)
+
+		for _, wins := range result.SeatWins {
+			row += percent(wins, result.Finished) + "\t"
+		}
+
+		fmt.Fprintln(table, row)
+	}
+
+	table.Flush()
+
+	// Rules the bots wouldn't play any differently under would only repeat the baseline
+	skipped := false
+	for _, variant := range variants {
+		if variant.unexercised == "" {
+			continue
+		}
+
+		if !skipped {
+			fmt.Println("\nNot exercised by these bots, so not played:")
+			skipped = true
+		}
+
+		fmt.Printf("  %s: %s\n", variant.name, variant.unexercised)
+	}
+
+	fmt.Println("\nEach game is a whole match. Moves, turns and reshuffles are averages per finished game. Win rates")
+	fmt.Println("are out of the finished games.")
+
+	return nil
+}
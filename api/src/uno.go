@@ -1,10 +1,9 @@
 package main
 
 import (
-	"errors"
 	"math/rand"
 	"sort"
-	"strings"
+	"time"
 )
 
 type GameState int
@@ -14,6 +13,7 @@ const (
 	GamePlaying   GameState = 1
 	GameComplete  GameState = 2
 	GameAbandoned GameState = 3
+	RoundComplete GameState = 4
 )
 
 type GameDirection int
@@ -23,43 +23,162 @@ const (
 	CounterClockwise GameDirection = -1
 )
 
+type ChoiceKind string
+
+const (
+	NoChoice            ChoiceKind = ""
+	SwapHandsChoice     ChoiceKind = "swapHands"
+	StartingColorChoice ChoiceKind = "startingColor"
+)
+
+// PendingChoice is a decision a player has to make before the game can continue
+type PendingChoice struct {
+	Kind   ChoiceKind `json:"kind"`
+	Player int        `json:"player"`
+}
+
+// WildDrawFourPlay records the hand a wild+4 was played from, so its victim can challenge it
+type WildDrawFourPlay struct {
+	Player int    `json:"player"`
+	Victim int    `json:"victim"`
+	Color  Color  `json:"color"`
+	Hand   []Card `json:"hand"`
+}
+
+// ChallengeResult is the outcome of a wild+4 challenge. The challenged hand is only shown to the
+// challenger.
+type ChallengeResult struct {
+	Challenger int    `json:"challenger"`
+	Challenged int    `json:"challenged"`
+	Guilty     bool   `json:"guilty"`
+	Hand       []Card `json:"hand"`
+}
+
 type Player struct {
-	Name  string   `json:"name"`
-	Cards []string `json:"cards"`
+	Name  string `json:"name"`
+	Cards []Card `json:"cards"`
+	Team  int    `json:"team"`
+
+	// Eliminated players sit out the rest of the match, watching the others play
+	Eliminated bool `json:"eliminated"`
+
+	// The difficulty of a computer controlled player, which is empty for people
+	Bot BotDifficulty `json:"bot,omitempty"`
+
+	// The session the player joined from, so they can be banned from the game
+	SessionId string `json:"sessionId,omitempty"`
+
+	// Disconnected players have lost their connection to the game, and have had their seat held for
+	// them since DisconnectedAt
+	Disconnected   bool      `json:"disconnected"`
+	DisconnectedAt time.Time `json:"disconnectedAt"`
+
+	// CalledUno is set when the player declares UNO on their last (or second to last) card, and
+	// UnoVulnerable when they went down to one card without declaring it and can still be caught
+	CalledUno     bool `json:"calledUno"`
+	UnoVulnerable bool `json:"unoVulnerable"`
+
+	// Score is the player's running total for the match, and RoundScores the points won each round
+	Score       int   `json:"score"`
+	RoundScores []int `json:"roundScores"`
 }
 
 type OtherPlayer struct {
-	Name     string `json:"name"`
-	NumCards int    `json:"numCards"`
+	Name          string        `json:"name"`
+	NumCards      int           `json:"numCards"`
+	Team          int           `json:"team"`
+	Eliminated    bool          `json:"eliminated"`
+	Bot           BotDifficulty `json:"bot,omitempty"`
+	Disconnected  bool          `json:"disconnected"`
+	CalledUno     bool          `json:"calledUno"`
+	UnoVulnerable bool          `json:"unoVulnerable"`
+	Score         int           `json:"score"`
+	RoundScores   []int         `json:"roundScores"`
 }
 
 type Game struct {
 	GameCode      string
 	GamePneumonic string
 
+	// The name of the player hosting the game, who decides when it starts and ends. Games stored
+	// before the host was recorded don't have HostRecorded set.
+	Host         string
+	HostRecorded bool
+
+	// The sessions of players the host has banned, who can't join again under any name
+	BannedSessions []string
+
+	// The seed the game was created with, and the current state of its random number generator
+	Seed int64
+	Rng  Rng
+
+	// Bots make their random choices from their own generator, so the cards dealt don't depend on
+	// whether bots are playing
+	BotRng Rng
+
 	State         GameState
+	Round         int
+	RoundWinner   int
+	MatchWinner   int
+	WinningTeam   int
 	Players       []Player
 	ActivePlayer  int
 	GameDirection GameDirection
 	MustDraw      int
-	WildColor     string
-	DrawPile      []string
-	DiscardPile   []string
+	HasDrawn      bool
+	DrawnCard     Card
+	WildColor     Color
+	DrawPile      []Card
+	DiscardPile   []Card
+	PendingChoice PendingChoice
+
+	// The last wild+4 played, while it can still be challenged, and the most recent challenge
+	WildDrawFour *WildDrawFourPlay
+	Challenge    *ChallengeResult
+
+	// The events caused by the last update to the game, including any moves bots made after it
+	Events []Event
+
+	Rules RuleSet
 }
 
 type PlayersGame struct {
 	State         GameState     `json:"state"`
+	Round         int           `json:"round"`
+	RoundWinner   int           `json:"roundWinner"`
+	MatchWinner   int           `json:"matchWinner"`
+	WinningTeam   int           `json:"winningTeam"`
 	ActivePlayer  int           `json:"activePlayer"`
 	GameDirection GameDirection `json:"direction"`
+	Host          string        `json:"host"`
 
 	You          Player        `json:"you"`
 	OtherPlayers []OtherPlayer `json:"otherPlayers"`
 
-	MustDraw         int    `json:"mustDraw"`
-	WildColor        string `json:"wildColor"`
-	DrawPileCount    int    `json:"drawPileCount"`
-	DiscardPileTop   string `json:"discardPileTop"`
-	DiscardPileCount int    `json:"discardPileCount"`
+	// In team games, your partner and the combined score of each team
+	Partner    *OtherPlayer `json:"partner"`
+	TeamScores []int        `json:"teamScores"`
+
+	MustDraw         int   `json:"mustDraw"`
+	HasDrawn         bool  `json:"hasDrawn"`
+	WildColor        Color `json:"wildColor"`
+	DrawPileCount    int   `json:"drawPileCount"`
+	DiscardPileTop   Card  `json:"discardPileTop"`
+	DiscardPileCount int   `json:"discardPileCount"`
+
+	// The choice the game is waiting on, and which kind of choice you need to make (if it's yours)
+	PendingChoice  PendingChoice `json:"pendingChoice"`
+	ChoiceRequired ChoiceKind    `json:"choiceRequired"`
+
+	// Whether you can challenge the wild+4 just played on you, and the result of the last challenge
+	CanChallenge bool             `json:"canChallenge"`
+	Challenge    *ChallengeResult `json:"challenge"`
+
+	// Every move you can make right now, and what happened in the last move
+	LegalMoves LegalMoves `json:"legalMoves"`
+	Events     []Event    `json:"events"`
+
+	Rules RuleSet `json:"rules"`
 }
 
 type GameError struct {
@@ -70,44 +189,114 @@ func (e *GameError) Error() string {
 	return e.message
 }
 
-var colors = [...]string{"R", "G", "B", "Y"}
-var numbers = [...]string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}
-var modifiers = [...]string{"rev", "+2", "skip"}
-var specials = [...]string{"wild", "wild", "wild", "wild", "wild+4", "wild+4", "wild+4", "wild+4"}
+var colors = [...]Color{Red, Green, Blue, Yellow}
+var modifiers = [...]Kind{Reverse, DrawTwo, Skip}
+var specials = [...]Kind{Wild, Wild, Wild, Wild, WildDrawFour, WildDrawFour, WildDrawFour, WildDrawFour}
 
 // GetPlayersGame returns a modifier Game object for a particular player, only showing information relevant
 // to them
+//
+// A playersIndex of -1 gives the view of someone watching the game, who doesn't see anyone's cards.
 func GetPlayersGame(game *Game, playersIndex int) *PlayersGame {
-	you := game.Players[playersIndex]
+	you := Player{Cards: []Card{}, RoundScores: []int{}}
+	if playersIndex != -1 {
+		you = game.Players[playersIndex]
+		you.SessionId = ""
+	}
 
 	// Collect the status of other players
 	otherPlayers := make([]OtherPlayer, 0)
 	for _, player := range game.Players {
 		otherPlayers = append(otherPlayers, OtherPlayer{
-			NumCards: len(player.Cards),
-			Name:     player.Name,
+			NumCards:      len(player.Cards),
+			Name:          player.Name,
+			Team:          player.Team,
+			Eliminated:    player.Eliminated,
+			Bot:           player.Bot,
+			Disconnected:  player.Disconnected,
+			CalledUno:     player.CalledUno,
+			UnoVulnerable: player.UnoVulnerable,
+			Score:         player.Score,
+			RoundScores:   player.RoundScores,
 		})
 	}
 
-	discardPileTop := ""
+	var partner *OtherPlayer
+	teamScores := []int{}
+
+	if game.Rules.Teams {
+		if partnerIndex := PartnerIndex(game, playersIndex); partnerIndex != -1 && playersIndex != -1 {
+			partner = &otherPlayers[partnerIndex]
+		}
+
+		teamScores = []int{TeamScore(game, 0), TeamScore(game, 1)}
+	}
+
+	discardPileTop := Card{}
 	if len(game.DiscardPile) > 0 {
 		discardPileTop = game.DiscardPile[0]
 	}
 
+	choiceRequired := NoChoice
+	if game.PendingChoice.Player == playersIndex && playersIndex != -1 {
+		choiceRequired = game.PendingChoice.Kind
+	}
+
+	// Everyone sees the outcome of a challenge, but only the challenger sees the hand
+	var challenge *ChallengeResult
+	if game.Challenge != nil {
+		result := *game.Challenge
+		if result.Challenger != playersIndex {
+			result.Hand = nil
+		}
+
+		challenge = &result
+	}
+
+	// Only the player who drew a card gets to see what it was
+	events := []Event{}
+	for _, event := range game.Events {
+		if event.Kind == CardDrawnEvent && event.Player != playersIndex {
+			event.Card = Card{}
+		}
+
+		events = append(events, event)
+	}
+
 	// Build the player's game object
 	return &PlayersGame{
 		State:         game.State,
+		Round:         game.Round,
+		RoundWinner:   game.RoundWinner,
+		MatchWinner:   game.MatchWinner,
+		WinningTeam:   game.WinningTeam,
 		ActivePlayer:  game.ActivePlayer,
 		GameDirection: game.GameDirection,
+		Host:          game.Host,
 
 		You:          you,
 		OtherPlayers: otherPlayers,
 
+		Partner:    partner,
+		TeamScores: teamScores,
+
 		MustDraw:         game.MustDraw,
+		HasDrawn:         game.HasDrawn,
 		WildColor:        game.WildColor,
 		DrawPileCount:    len(game.DrawPile),
 		DiscardPileTop:   discardPileTop,
 		DiscardPileCount: len(game.DiscardPile),
+
+		PendingChoice:  game.PendingChoice,
+		ChoiceRequired: choiceRequired,
+
+		CanChallenge: canChallenge(game, playersIndex),
+		Challenge:    challenge,
+
+		LegalMoves: GetLegalMoves(game, playersIndex),
+		Events:     events,
+
+		Rules: game.Rules,
 	}
 }
 
@@ -123,41 +312,41 @@ func GetPlayerIndex(game *Game, playerName string) int {
 }
 
 // Deck returns a new uno deck
-func Deck() []string {
-	var cards []string
+func Deck() []Card {
+	var cards []Card
 
 	for _, color := range colors {
-		for _, number := range numbers {
-			cards = append(cards, color+number)
+		for number := 0; number <= 9; number++ {
+			cards = append(cards, NumberCard(color, number))
 		}
 
 		// Uno includes two sets of number cards per color, expect 0 (don't know why...)
-		for _, number := range numbers[1:] {
-			cards = append(cards, color+number)
+		for number := 1; number <= 9; number++ {
+			cards = append(cards, NumberCard(color, number))
 		}
 
 		for _, modifier := range modifiers {
-			cards = append(cards, color+modifier)
-			cards = append(cards, color+modifier)
+			cards = append(cards, ActionCard(color, modifier))
+			cards = append(cards, ActionCard(color, modifier))
 		}
 	}
 
 	for _, special := range specials {
-		cards = append(cards, special)
+		cards = append(cards, WildCard(special))
 	}
 
 	return cards
 }
 
-// Shuffle returns a new deck that has been shuffled
-func Shuffle(deck []string) []string {
-	newDeck := []string{}
+// Shuffle returns a new deck that has been shuffled with the given generator
+func Shuffle(rng *rand.Rand, deck []Card) []Card {
+	newDeck := []Card{}
 
 	for _, card := range deck {
 		newDeck = append(newDeck, card)
 	}
 
-	rand.Shuffle(
+	rng.Shuffle(
 		len(newDeck),
 		func(i, j int) { newDeck[i], newDeck[j] = newDeck[j], newDeck[i] },
 	)
@@ -165,58 +354,265 @@ func Shuffle(deck []string) []string {
 	return newDeck
 }
 
-// EmptyGame returns a new Game instance
-func EmptyGame(gameCode string, gamePneumonic string) *Game {
-	return &Game{
+// EmptyGame returns a new Game instance. Every shuffle and random choice in the game is made from the
+// seed, so two games with the same seed and the same actions play out identically.
+func EmptyGame(gameCode string, gamePneumonic string, seed int64) *Game {
+	game := &Game{
 		GameCode:      gameCode,
 		GamePneumonic: gamePneumonic,
+		HostRecorded:  true,
+		Seed:          seed,
+		Rng:           NewRng(seed),
+		BotRng:        NewRng(^seed),
 		State:         GameCreated,
+		RoundWinner:   -1,
+		MatchWinner:   -1,
+		WinningTeam:   -1,
 		ActivePlayer:  0,
 		GameDirection: Clockwise,
 		WildColor:     "R",
-		DrawPile:      Shuffle(Deck()),
-		DiscardPile:   []string{},
+		DiscardPile:   []Card{},
 		Players:       []Player{},
+		Rules:         DefaultRuleSet(),
 	}
+
+	game.DrawPile = Shuffle(random(game), Deck())
+
+	return game
 }
 
-// AddPlayer returns a game with a new player added
+// UpdateSettings returns a game with the given house rule settings applied. Rules can only be changed
+// in the lobby, before the game has started.
+func UpdateSettings(game *Game, settings map[string]string) (*Game, error) {
+	if game.State != GameCreated {
+		return game, &GameError{message: "Settings can only be changed before the game starts"}
+	}
+
+	rules, err := UpdateRuleSet(game.Rules, settings)
+	if err != nil {
+		return game, err
+	}
+
+	game.Rules = rules
+
+	return game, nil
+}
+
+// AddPlayer returns a game with a new player added. Players are seated so that the teams alternate
+// around the table.
 func AddPlayer(game *Game, name string) *Game {
 	game.Players = append(game.Players, Player{
-		Name:  name,
-		Cards: []string{},
+		Name:        name,
+		Cards:       []Card{},
+		Team:        len(game.Players) % 2,
+		RoundScores: []int{},
 	})
 
 	return game
 }
 
+// assignTeams reseats the players into alternating teams, after someone leaves the table
+func assignTeams(game *Game) *Game {
+	for i := range game.Players {
+		game.Players[i].Team = i % 2
+	}
+
+	return game
+}
+
+// PartnerIndex returns the index of the player's partner, sitting opposite them, or -1 if there are
+// no teams
+func PartnerIndex(game *Game, playerIndex int) int {
+	if !game.Rules.Teams || len(game.Players) != 4 {
+		return -1
+	}
+
+	return (playerIndex + 2) % 4
+}
+
+// TeamScore returns the combined match score of the players on a team
+func TeamScore(game *Game, team int) int {
+	score := 0
+	for _, player := range game.Players {
+		if player.Team == team {
+			score += player.Score
+		}
+	}
+
+	return score
+}
+
 // RemovePlayer returns a game with the given player removed
 func RemovePlayer(game *Game, name string) *Game {
 	index := GetPlayerIndex(game, name)
-	if index > 0 {
-		// Release the players cards back into the deck
-		game.DrawPile = append(game.DrawPile, Shuffle(game.Players[index].Cards)...)
+	if index >= 0 {
+		game = releaseCards(game, index)
+
+		if game.Host == name {
+			game = handOffHost(game, index)
+		}
+
+		// If it was the player's turn, it passes to whoever was next. A draw penalty the player owed
+		// leaves with them, rather than falling on someone it wasn't played on.
+		active := game.ActivePlayer
+		if active == index {
+			active = nextSeat(game, index)
+			game.HasDrawn = false
+			game.DrawnCard = Card{}
+			game.MustDraw = 0
+			game.WildDrawFour = nil
+		}
 
 		// Remove the player
 		game.Players = append(game.Players[:index], game.Players[index+1:]...)
+		game = assignTeams(game)
 
-		// Ensure the current player isn't active
-		if game.ActivePlayer == index {
-			game = AdvancePlayer(game)
+		// Everyone sitting after the player moves up a seat
+		active = seatAfterRemoval(active, index)
+		if active >= len(game.Players) {
+			active = 0
+		}
+
+		game.ActivePlayer = active
+
+		// A choice the player was making is dropped, as nobody can make it for them
+		if game.PendingChoice.Kind != NoChoice {
+			if game.PendingChoice.Player == index {
+				game.PendingChoice = PendingChoice{}
+			} else {
+				game.PendingChoice.Player = seatAfterRemoval(game.PendingChoice.Player, index)
+			}
+		}
+
+		// A wild+4 can't be challenged, or its outcome shown, once either side of it has left
+		if play := game.WildDrawFour; play != nil {
+			if play.Player == index || play.Victim == index {
+				game.WildDrawFour = nil
+			} else {
+				play.Player = seatAfterRemoval(play.Player, index)
+				play.Victim = seatAfterRemoval(play.Victim, index)
+			}
+		}
+
+		if result := game.Challenge; result != nil {
+			if result.Challenger == index || result.Challenged == index {
+				game.Challenge = nil
+			} else {
+				result.Challenger = seatAfterRemoval(result.Challenger, index)
+				result.Challenged = seatAfterRemoval(result.Challenged, index)
+			}
 		}
 	}
 
 	return game
 }
 
-// Draw returns a game and an array with numCards cards
-func Draw(game *Game, numCards int) (*Game, []string) {
-	cards := []string{}
+// seatAfterRemoval returns the seat a player moves to once the player at removedIndex has left the table
+func seatAfterRemoval(seat int, removedIndex int) int {
+	if seat > removedIndex {
+		return seat - 1
+	}
+
+	return seat
+}
+
+// handOffHost passes hosting from the player to the next player round the table who's connected and
+// isn't a bot, or failing that to the next player who isn't a bot. If there's nobody to take over, the
+// game is left without a host until a player joins or reconnects.
+func handOffHost(game *Game, playerIndex int) *Game {
+	game.Host = ""
+
+	for _, needConnected := range []bool{true, false} {
+		for i := 1; i < len(game.Players); i++ {
+			player := game.Players[(playerIndex+i)%len(game.Players)]
+
+			if player.Bot == NoBot && (!needConnected || !player.Disconnected) {
+				game.Host = player.Name
+				return game
+			}
+		}
+	}
+
+	return game
+}
+
+// BanPlayer returns a game where the session the player joined from is banned from joining again. The
+// player still has to be removed from the game.
+func BanPlayer(game *Game, name string) *Game {
+	index := GetPlayerIndex(game, name)
+	if index == -1 {
+		return game
+	}
+
+	if sessionId := game.Players[index].SessionId; sessionId != "" && !IsBanned(game, sessionId) {
+		game.BannedSessions = append(game.BannedSessions, sessionId)
+	}
+
+	return game
+}
+
+// IsBanned returns true if the session has been banned from the game
+func IsBanned(game *Game, sessionId string) bool {
+	for _, banned := range game.BannedSessions {
+		if banned == sessionId {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TransferHost returns a game hosted by another player. Bots can't host.
+func TransferHost(game *Game, name string) (*Game, error) {
+	index := GetPlayerIndex(game, name)
+	if index == -1 {
+		return game, &GameError{message: "That player isn't in the game"}
+	}
+
+	if game.Players[index].Bot != NoBot {
+		return game, &GameError{message: "Bots can't host the game"}
+	}
+
+	game.Host = name
+
+	return game, nil
+}
+
+// releaseCards returns the player's cards to the draw pile
+func releaseCards(game *Game, playerIndex int) *Game {
+	game.DrawPile = append(game.DrawPile, Shuffle(random(game), game.Players[playerIndex].Cards)...)
+	game.Players[playerIndex].Cards = []Card{}
+
+	return game
+}
+
+// ActivePlayerCount returns the number of players who haven't been eliminated
+func ActivePlayerCount(game *Game) int {
+	count := 0
+	for _, player := range game.Players {
+		if !player.Eliminated {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Draw returns a game and an array with numCards cards. If every card is already in a player's hand,
+// fewer cards are returned.
+func Draw(game *Game, numCards int) (*Game, []Card) {
+	cards := []Card{}
 
 	for i := 0; i < numCards; i++ {
 		if len(game.DrawPile) == 0 {
-			game.DrawPile = Shuffle(game.DiscardPile[1:])
+			if len(game.DiscardPile) <= 1 {
+				break
+			}
+
+			game.DrawPile = Shuffle(random(game), game.DiscardPile[1:])
 			game.DiscardPile = game.DiscardPile[0:1]
+
+			emit(game, Event{Kind: DrawPileReshuffledEvent, Player: -1, Target: -1, Count: len(game.DrawPile)})
 		}
 
 		cards = append(cards, game.DrawPile[0])
@@ -226,17 +622,32 @@ func Draw(game *Game, numCards int) (*Game, []string) {
 	return game, cards
 }
 
-// DrawHands returns a game with all the players getting 7 cards from the draw pile
+// DrawHands returns a game with all the players getting a hand of cards from the draw pile
 func DrawHands(game *Game) *Game {
 	// Reset the game
+	game.Round++
+	game.RoundWinner = -1
+	game.MatchWinner = -1
+	game.WinningTeam = -1
 	game.GameDirection = Clockwise
-	game.DrawPile = Shuffle(Deck())
-	game.DiscardPile = []string{}
-
-	// Each player starts with 7 cards
+	game.MustDraw = 0
+	game.PendingChoice = PendingChoice{}
+	game.WildDrawFour = nil
+	game.Challenge = nil
+	game.DrawPile = Shuffle(random(game), Deck())
+	game.DiscardPile = []Card{}
+
+	// Each player still in the match starts with the same number of cards
 	for i := range game.Players {
-		// Draw 7 cards from the deck
-		game, game.Players[i].Cards = Draw(game, 7)
+		game.Players[i].CalledUno = false
+		game.Players[i].UnoVulnerable = false
+
+		if game.Players[i].Eliminated {
+			game.Players[i].Cards = []Card{}
+			continue
+		}
+
+		game, game.Players[i].Cards = Draw(game, game.Rules.HandSize)
 
 		// Sort the player cards after adding
 		game = SortPlayerCards(game, i)
@@ -244,15 +655,125 @@ func DrawHands(game *Game) *Game {
 
 	// Draw a card for the discard pile
 	game, game.DiscardPile = Draw(game, 1)
-	game.WildColor = colors[rand.Intn(len(colors))]
+	game.WildColor = colors[random(game).Intn(len(colors))]
+
+	// A wild+4 can't start the discard pile, so it goes back in and the deck is reshuffled
+	for game.Rules.ReshuffleStartingWildFour && game.DiscardPile[0].Kind == WildDrawFour {
+		game.DrawPile = Shuffle(random(game), append(game.DrawPile, game.DiscardPile[0]))
+		game, game.DiscardPile = Draw(game, 1)
+	}
 
 	return game
 }
 
+// validateStart returns an error if the players and rules don't make for a game that can be dealt and
+// started
+func validateStart(game *Game) error {
+	if len(game.Players) < 2 {
+		return &GameError{message: "You need at least two players to start, add a bot to play against"}
+	}
+
+	if game.Rules.Teams && len(game.Players) != 4 {
+		return &GameError{message: "Team games need exactly four players"}
+	}
+
+	if game.Rules.Teams && game.Rules.Elimination {
+		return &GameError{message: "Team games can't be played as elimination"}
+	}
+
+	// Every hand, and the first card of the discard pile, has to come out of one deck
+	if ActivePlayerCount(game)*game.Rules.HandSize+1 > len(Deck()) {
+		return &GameError{message: "There aren't enough cards in the deck to deal that many to every player"}
+	}
+
+	return nil
+}
+
 // StartGame returns a game which has been started
-func StartGame(game *Game) *Game {
+func StartGame(game *Game) (*Game, error) {
+	if err := validateStart(game); err != nil {
+		return game, err
+	}
+
 	game.State = GamePlaying
-	game.ActivePlayer = rand.Intn(len(game.Players))
+	game.ActivePlayer = random(game).Intn(len(game.Players))
+
+	// Eliminated players don't take turns
+	if game.Players[game.ActivePlayer].Eliminated {
+		game = AdvancePlayer(game)
+	}
+
+	emit(game, Event{Kind: RoundStartedEvent, Player: game.ActivePlayer, Target: -1, Card: game.DiscardPile[0], Count: game.Round})
+
+	return applyStartingCard(game), nil
+}
+
+// applyStartingCard applies the card that was turned over to start the discard pile to the first player
+func applyStartingCard(game *Game) *Game {
+	topCard := game.DiscardPile[0]
+
+	if topCard.IsWild() && game.Rules.ChooseStartingColor {
+		game.PendingChoice = PendingChoice{Kind: StartingColorChoice, Player: game.ActivePlayer}
+	}
+
+	if topCard.Kind != Wild && game.Rules.ApplyStartingAction {
+		skipped := game.ActivePlayer
+
+		game = ApplyModifiers(game)
+		game = emitModifier(game, topCard, -1, skipped)
+	}
+
+	return game
+}
+
+// ChooseStartingColor sets the color of a wild that was turned over to start the discard pile
+func ChooseStartingColor(game *Game, playerIndex int, color Color) (*Game, error) {
+	if game.PendingChoice.Kind != StartingColorChoice || game.PendingChoice.Player != playerIndex {
+		return game, &GameError{message: "You don't have a color to choose"}
+	}
+
+	for _, validColor := range colors {
+		if color == validColor {
+			game.WildColor = color
+			game.PendingChoice = PendingChoice{}
+
+			emit(game, Event{Kind: ColorChosenEvent, Player: playerIndex, Target: -1, Color: color})
+
+			return game, nil
+		}
+	}
+
+	return game, &GameError{message: "That isn't a color"}
+}
+
+// NextRound deals and starts the next round of a match
+func NextRound(game *Game) (*Game, error) {
+	if game.State != RoundComplete {
+		return game, &GameError{message: "The round isn't over yet"}
+	}
+
+	if err := validateStart(game); err != nil {
+		return game, err
+	}
+
+	game = DrawHands(game)
+
+	return StartGame(game)
+}
+
+// ResetMatch returns a game back in the lobby, with the scores cleared for a new match
+func ResetMatch(game *Game) *Game {
+	game.State = GameCreated
+	game.Round = 0
+	game.RoundWinner = -1
+	game.MatchWinner = -1
+	game.WinningTeam = -1
+
+	for i := range game.Players {
+		game.Players[i].Score = 0
+		game.Players[i].RoundScores = []int{}
+		game.Players[i].Eliminated = false
+	}
 
 	return game
 }
@@ -265,7 +786,7 @@ func EndGame(game *Game) *Game {
 }
 
 // RemovePlayerCard removes a card from a players hand and returns it
-func RemovePlayerCard(game *Game, playerIndex int, cardIndex int) (*Game, string) {
+func RemovePlayerCard(game *Game, playerIndex int, cardIndex int) (*Game, Card) {
 	playerCards := game.Players[playerIndex].Cards
 	card := playerCards[cardIndex]
 
@@ -278,58 +799,52 @@ func RemovePlayerCard(game *Game, playerIndex int, cardIndex int) (*Game, string
 }
 
 // DiscardCard places the given card on top of the discard pile
-func DiscardCard(game *Game, card string) *Game {
-	game.DiscardPile = append([]string{card}, game.DiscardPile...)
+func DiscardCard(game *Game, card Card) *Game {
+	game.DiscardPile = append([]Card{card}, game.DiscardPile...)
 	return game
 }
 
-// ValidateCardPlay player returns an error if the give play is invalid. Otherwise
-// it returns nil.
-func ValidateCardPlay(topCard string, wildColor string, card string) error {
-	// If the new card is a wild card (which can be played on anything)
-	if strings.HasPrefix(card, "wild") {
-		return nil
-	}
-
-	// If the new card is being placed on a wild
-	if strings.HasPrefix(topCard, "wild") && len(wildColor) == 1 {
-		// Playing a wild on a wild
-		if strings.HasPrefix(card, "wild") {
-			return nil
-		}
+// ValidateCardPlay player returns an error if the given card can't be played on the game's discard
+// pile. Otherwise it returns nil.
+func ValidateCardPlay(game *Game, card Card) error {
+	topCard := game.DiscardPile[0]
 
-		// Otherwise, the colors must match
-		if card[0] == wildColor[0] {
-			return nil
-		}
+	// A player with a draw penalty pending may only pass it on, if the rules allow stacking
+	if game.MustDraw > 0 {
+		return validateStack(game, topCard, card)
 	}
 
-	// If the colors match
-	if card[0] == topCard[0] {
+	// If the new card is a wild card (which can be played on anything)
+	if card.IsWild() {
 		return nil
 	}
 
-	// Both cards are a reverse
-	if strings.HasSuffix(card, "rev") && strings.HasSuffix(topCard, "rev") {
+	// If the colors match, or the color chosen for a wild
+	if card.Color == currentColor(game) {
 		return nil
 	}
 
-	// Both cards are a +2
-	if strings.HasSuffix(card, "+2") && strings.HasSuffix(topCard, "+2") {
+	// Both cards have the same number, or are the same action
+	if card.SameSymbol(topCard) {
 		return nil
 	}
 
-	// Both cards are a skip
-	if strings.HasSuffix(card, "skip") && strings.HasSuffix(topCard, "skip") {
+	return &GameError{message: "Can't play that card"}
+}
+
+// validateStack returns an error unless the card can be stacked on the pending draw penalty
+func validateStack(game *Game, topCard Card, card Card) error {
+	// A +2 can be answered with another +2, regardless of color
+	if game.Rules.StackDrawTwo && card.Kind == DrawTwo && topCard.Kind == DrawTwo {
 		return nil
 	}
 
-	// Otherwise, both cards are number cards. Ensure the numbers match
-	if card[1] == topCard[1] {
+	// A wild+4 can be answered with another wild+4, or played on top of a +2
+	if game.Rules.StackDrawFour && card.Kind == WildDrawFour && topCard.DrawPenalty() > 0 {
 		return nil
 	}
 
-	return &GameError{message: "Can't play that card"}
+	return &GameError{message: "player cannot play, they must draw"}
 }
 
 func reverseDirection(direction GameDirection) GameDirection {
@@ -345,22 +860,19 @@ func reverseDirection(direction GameDirection) GameDirection {
 func ApplyModifiers(game *Game) *Game {
 	topCard := game.DiscardPile[0]
 
-	// if MustDraw > 0, then the subsequent players turn must be used to draw 2 from the pile
-	if strings.HasSuffix(topCard, "+2") {
-		game.MustDraw = 2
-	} else if strings.HasSuffix(topCard, "+4") {
-		game.MustDraw = 4
-	}
+	// if MustDraw > 0, then the subsequent players turn must be used to draw from the pile. Stacked
+	// penalties add on to whatever the previous player passed along.
+	game.MustDraw += topCard.DrawPenalty()
 
-	if strings.HasSuffix(topCard, "skip") {
+	if topCard.Kind == Skip {
 		game = AdvancePlayer(game)
 	}
 
-	if strings.HasSuffix(topCard, "rev") {
+	if topCard.Kind == Reverse {
 		game.GameDirection = reverseDirection(game.GameDirection)
 
 		// People expect a reverse to skip the next player (those that's now really how it works...)
-		if len(game.Players) == 2 {
+		if ActivePlayerCount(game) == 2 && game.Rules.TwoPlayerReverseSkip {
 			game = AdvancePlayer(game)
 		}
 	}
@@ -368,104 +880,726 @@ func ApplyModifiers(game *Game) *Game {
 	return game
 }
 
-// AdvancePlayer increments the ActivePlayer by 1 in the current GameDirection
-func AdvancePlayer(game *Game) *Game {
-	newPlayer := (int(game.ActivePlayer) + int(game.GameDirection))
+// emitModifier records the effect of a skip, reverse, +2 or wild+4 once it has been applied. The
+// skipped player is the one whose turn a skip (or a reverse between two players) passes over.
+func emitModifier(game *Game, card Card, playerIndex int, skipped int) *Game {
+	if card.Kind == Reverse {
+		emit(game, Event{Kind: DirectionReversedEvent, Player: playerIndex, Target: -1})
+	}
 
-	if newPlayer >= len(game.Players) {
-		newPlayer -= len(game.Players)
+	reverseSkips := card.Kind == Reverse && ActivePlayerCount(game) == 2 && game.Rules.TwoPlayerReverseSkip
+	if card.Kind == Skip || reverseSkips {
+		emit(game, Event{Kind: PlayerSkippedEvent, Player: skipped, Target: -1})
 	}
 
-	if newPlayer < 0 {
-		newPlayer += len(game.Players)
+	if card.DrawPenalty() > 0 {
+		emit(game, Event{Kind: DrawPenaltyEvent, Player: game.ActivePlayer, Target: -1, Count: game.MustDraw})
 	}
 
-	game.ActivePlayer = newPlayer
+	return game
+}
+
+// nextSeat returns the index of the next player after the given seat in the current GameDirection,
+// passing over anyone who has been eliminated
+func nextSeat(game *Game, seat int) int {
+	newPlayer := seat
+
+	for range game.Players {
+		newPlayer += int(game.GameDirection)
+
+		if newPlayer >= len(game.Players) {
+			newPlayer -= len(game.Players)
+		}
+
+		if newPlayer < 0 {
+			newPlayer += len(game.Players)
+		}
+
+		if !game.Players[newPlayer].Eliminated {
+			break
+		}
+	}
+
+	return newPlayer
+}
+
+// AdvancePlayer increments the ActivePlayer by 1 in the current GameDirection
+func AdvancePlayer(game *Game) *Game {
+	game.ActivePlayer = nextSeat(game, game.ActivePlayer)
+	game.HasDrawn = false
+	game.DrawnCard = Card{}
 
 	return game
 }
 
-// CheckForWinner checks if any player has 0 cards, and if so sets the state to complete
+// CheckForWinner checks if any player has 0 cards, and if so scores the round and sets the state to
+// either round or game complete
 func CheckForWinner(game *Game) *Game {
+	for i, player := range game.Players {
+		if len(player.Cards) == 0 && !player.Eliminated {
+			return ScoreRound(game, i)
+		}
+	}
+
+	return game
+}
+
+// ScoreRound awards the winner of the round the points for every card left in their opponents' hands,
+// and declares them the match winner if they have reached the target score. In team games, the cards
+// left in the partner's hand don't count, and the partners' scores are combined.
+func ScoreRound(game *Game, winnerIndex int) *Game {
+	winningTeam := game.Players[winnerIndex].Team
+
+	// A +2 or wild+4 played as the last card is still drawn before the hands are counted
+	if game.MustDraw > 0 {
+		var penalty []Card
+		game, penalty = Draw(game, game.MustDraw)
+		game.Players[game.ActivePlayer].Cards = append(game.Players[game.ActivePlayer].Cards, penalty...)
+		game.MustDraw = 0
+	}
+
+	points := 0
 	for _, player := range game.Players {
-		if len(player.Cards) == 0 {
-			game.State = GameComplete
-			return game
+		if game.Rules.Teams && player.Team == winningTeam {
+			continue
+		}
+
+		for _, card := range player.Cards {
+			points += card.Points()
 		}
 	}
 
+	for i := range game.Players {
+		roundScore := 0
+		if i == winnerIndex {
+			roundScore = points
+		}
+
+		game.Players[i].Score += roundScore
+		game.Players[i].RoundScores = append(game.Players[i].RoundScores, roundScore)
+	}
+
+	game.RoundWinner = winnerIndex
+	game.State = RoundComplete
+
+	emit(game, Event{Kind: RoundWonEvent, Player: winnerIndex, Target: -1, Count: points})
+
+	if game.Rules.Elimination {
+		return eliminatePlayer(game)
+	}
+
+	score := game.Players[winnerIndex].Score
+	if game.Rules.Teams {
+		game.WinningTeam = winningTeam
+		score = TeamScore(game, winningTeam)
+	}
+
+	if game.Rules.TargetScore == 0 || score >= game.Rules.TargetScore {
+		game.MatchWinner = winnerIndex
+		game.State = GameComplete
+
+		emit(game, Event{Kind: MatchWonEvent, Player: winnerIndex, Target: -1})
+	}
+
+	return game
+}
+
+// eliminatePlayer knocks out the player left holding the most points at the end of a round. Ties go
+// to whoever holds more cards, then to whoever sits first after the round winner. Once only one player
+// is left, they win the match.
+func eliminatePlayer(game *Game) *Game {
+	eliminated := -1
+	mostPoints := -1
+	mostCards := -1
+
+	for offset := 1; offset < len(game.Players); offset++ {
+		i := (game.RoundWinner + offset) % len(game.Players)
+		player := game.Players[i]
+		if player.Eliminated {
+			continue
+		}
+
+		points := 0
+		for _, card := range player.Cards {
+			points += card.Points()
+		}
+
+		if points > mostPoints || (points == mostPoints && len(player.Cards) > mostCards) {
+			eliminated = i
+			mostPoints = points
+			mostCards = len(player.Cards)
+		}
+	}
+
+	if eliminated == -1 {
+		return game
+	}
+
+	game = releaseCards(game, eliminated)
+	game.Players[eliminated].Eliminated = true
+
+	emit(game, Event{Kind: PlayerEliminatedEvent, Player: eliminated, Target: -1})
+
+	if ActivePlayerCount(game) == 1 {
+		for i, player := range game.Players {
+			if !player.Eliminated {
+				game.MatchWinner = i
+			}
+		}
+
+		game.State = GameComplete
+
+		emit(game, Event{Kind: MatchWonEvent, Player: game.MatchWinner, Target: -1})
+	}
+
 	return game
 }
 
 // PlayCard takes a card from the active player's hand and places it on the top of the discard pile
-func PlayCard(game *Game, cardIndex int, wildColor string) (*Game, error) {
-	topCard := game.DiscardPile[0]
+func PlayCard(game *Game, cardIndex int, wildColor Color) (*Game, error) {
 	playerIndex := game.ActivePlayer
-	cardToPlay := game.Players[playerIndex].Cards[cardIndex]
 
-	// Check if the player is allows to play
-	if game.MustDraw > 0 {
-		return game, errors.New("player cannot play, they must draw")
+	// Validate the card move
+	if err := validatePlayCard(game, cardIndex); err != nil {
+		return game, err
 	}
 
-	if len(wildColor) > 0 {
+	cardToPlay := game.Players[playerIndex].Cards[cardIndex]
+	if cardToPlay.IsWild() && wildColor != NoColor {
+		if _, ok := parseColor(string(wildColor)); !ok {
+			return game, &GameError{message: "That isn't a color"}
+		}
+	}
+
+	// The color a wild+4 is played on, in case it's challenged
+	colorInPlay := currentColor(game)
+
+	if cardToPlay.IsWild() && wildColor != NoColor {
 		game.WildColor = wildColor
 	}
 
-	// Validate the card move
-	if err := ValidateCardPlay(topCard, game.WildColor, cardToPlay); err != nil {
-		return game, err
+	emit(game, Event{Kind: CardPlayedEvent, Player: playerIndex, Target: -1, Card: cardToPlay})
+
+	if cardToPlay.IsWild() {
+		emit(game, Event{Kind: ColorChosenEvent, Player: playerIndex, Target: -1, Color: game.WildColor})
 	}
 
+	// Playing a card closes the window to catch anyone who didn't call UNO, and to challenge the last
+	// wild+4
+	game = closeUnoWindow(game)
+	game.WildDrawFour = nil
+	game.Challenge = nil
+
 	// Remove the players card from their hand, and discard it on top of the DiscardPile
 	game, playedCard := RemovePlayerCard(game, playerIndex, cardIndex)
 	game = DiscardCard(game, playedCard)
 
+	return finishPlay(game, playerIndex, playedCard, colorInPlay), nil
+}
+
+// validatePlayCard returns an error if the active player can't play the card at the given index in
+// their hand
+func validatePlayCard(game *Game, cardIndex int) error {
+	if err := validateNoPendingChoice(game); err != nil {
+		return err
+	}
+
+	cards := game.Players[game.ActivePlayer].Cards
+	if cardIndex < 0 || cardIndex >= len(cards) {
+		return &GameError{message: "Invalid card index"}
+	}
+
+	card := cards[cardIndex]
+	if err := ValidateCardPlay(game, card); err != nil {
+		return err
+	}
+
+	if game.HasDrawn && game.Rules.PlayDrawnOnly && card != game.DrawnCard {
+		return &GameError{message: "You can only play the card you drew"}
+	}
+
+	return nil
+}
+
+// finishPlay applies the effects of the card the player just discarded, and moves the game on to the
+// next player
+func finishPlay(game *Game, playerIndex int, playedCard Card, colorInPlay Color) *Game {
+	// Keep the rest of the hand a wild+4 was played from, so the next player can challenge it. A wild+4
+	// stacked on a draw penalty was the only way to answer it, so it can't be challenged.
+	if playedCard.Kind == WildDrawFour && game.Rules.WildDrawFourChallenge && game.MustDraw == 0 {
+		game.WildDrawFour = &WildDrawFourPlay{
+			Player: playerIndex,
+			Color:  colorInPlay,
+			Hand:   append([]Card{}, game.Players[playerIndex].Cards...),
+		}
+	}
+
+	// Going down to one card without calling UNO leaves the player open to being caught
+	if len(game.Players[playerIndex].Cards) == 1 && !game.Players[playerIndex].CalledUno {
+		game.Players[playerIndex].UnoVulnerable = true
+	}
+
+	// Under Seven-O, a 7 swaps hands with a player of their choosing and a 0 passes every hand along.
+	// A player who went out with their last card doesn't swap.
+	if game.Rules.SevenO && len(game.Players[playerIndex].Cards) > 0 {
+		if playedCard.IsNumber(7) {
+			// The turn is finished once the player chooses who to swap with
+			game.PendingChoice = PendingChoice{Kind: SwapHandsChoice, Player: playerIndex}
+			return game
+		}
+
+		if playedCard.IsNumber(0) {
+			game = RotateHands(game)
+			emit(game, Event{Kind: HandsRotatedEvent, Player: playerIndex, Target: -1})
+		}
+	}
+
+	skipped := nextSeat(game, playerIndex)
+
 	// Apply modifier cards (+2, +4, skip, reverse)
 	game = ApplyModifiers(game)
 
 	// Move to the next player
 	game = AdvancePlayer(game)
 
+	game = emitModifier(game, playedCard, playerIndex, skipped)
+
+	if game.WildDrawFour != nil {
+		game.WildDrawFour.Victim = game.ActivePlayer
+	}
+
 	// Check if any player has won
-	game = CheckForWinner(game)
+	return CheckForWinner(game)
+}
+
+// PlayCards plays several number cards with the same number in one move. The first card has to be
+// playable on the discard pile, and the last one played ends up on top. Either every card is played,
+// or none of them are.
+func PlayCards(game *Game, cardIndexes []int, wildColor Color) (*Game, error) {
+	if len(cardIndexes) == 0 {
+		return game, &GameError{message: "Choose at least one card to play"}
+	}
+
+	if len(cardIndexes) == 1 {
+		return PlayCard(game, cardIndexes[0], wildColor)
+	}
+
+	if !game.Rules.MultiPlay {
+		return game, &GameError{message: "Only one card can be played at a time in this game"}
+	}
+
+	playerIndex := game.ActivePlayer
+	hand := game.Players[playerIndex].Cards
+
+	if err := validateNoPendingChoice(game); err != nil {
+		return game, err
+	}
+
+	if game.HasDrawn && game.Rules.PlayDrawnOnly {
+		return game, &GameError{message: "You can only play the card you drew"}
+	}
+
+	cards := []Card{}
+	seen := map[int]bool{}
+
+	for _, cardIndex := range cardIndexes {
+		if cardIndex < 0 || cardIndex >= len(hand) || seen[cardIndex] {
+			return game, &GameError{message: "Invalid card index"}
+		}
+
+		seen[cardIndex] = true
+		cards = append(cards, hand[cardIndex])
+	}
+
+	for _, card := range cards {
+		if card.Kind != Number || card.Value != cards[0].Value {
+			return game, &GameError{message: "You can only play several cards with the same number"}
+		}
+	}
+
+	if err := ValidateCardPlay(game, cards[0]); err != nil {
+		return game, err
+	}
+
+	game = closeUnoWindow(game)
+	game.WildDrawFour = nil
+	game.Challenge = nil
+
+	// Remove the cards from the highest index down, so the remaining indexes stay put
+	sorted := append([]int{}, cardIndexes...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+
+	for _, cardIndex := range sorted {
+		game, _ = RemovePlayerCard(game, playerIndex, cardIndex)
+	}
+
+	for _, card := range cards {
+		game = DiscardCard(game, card)
+		emit(game, Event{Kind: CardPlayedEvent, Player: playerIndex, Target: -1, Card: card})
+	}
+
+	return finishPlay(game, playerIndex, cards[len(cards)-1], currentColor(game)), nil
+}
+
+// JumpIn lets any player play a card identical to the top of the discard pile, even when it isn't their
+// turn. The player becomes the active player and play continues from their seat. The discardPileCount
+// is the size of the discard pile the player saw when they jumped in; if another card has been played
+// since, someone else got there first and the jump in is rejected.
+func JumpIn(game *Game, playerIndex int, cardIndex int, discardPileCount int) (*Game, error) {
+	if discardPileCount != len(game.DiscardPile) {
+		return game, &GameError{message: "Too slow, another card was played first"}
+	}
+
+	if err := validateJumpIn(game, playerIndex, cardIndex); err != nil {
+		return game, err
+	}
+
+	game.ActivePlayer = playerIndex
+	game.HasDrawn = false
+	game.DrawnCard = Card{}
+
+	emit(game, Event{Kind: JumpedInEvent, Player: playerIndex, Target: -1})
+
+	return PlayCard(game, cardIndex, "")
+}
+
+// validateJumpIn returns an error if the player can't jump in with the card at the given index in their
+// hand
+func validateJumpIn(game *Game, playerIndex int, cardIndex int) error {
+	if !game.Rules.JumpIn {
+		return &GameError{message: "Jumping in isn't allowed in this game"}
+	}
+
+	if game.State != GamePlaying {
+		return &GameError{message: "The game isn't being played"}
+	}
+
+	if game.MustDraw > 0 {
+		return &GameError{message: "Can't jump in while a player must draw"}
+	}
+
+	if err := validateNoPendingChoice(game); err != nil {
+		return err
+	}
+
+	cards := game.Players[playerIndex].Cards
+	if cardIndex < 0 || cardIndex >= len(cards) {
+		return &GameError{message: "Invalid card index"}
+	}
+
+	// Wilds don't have a color of their own, so they can never be identical
+	card := cards[cardIndex]
+	if card != game.DiscardPile[0] || card.IsWild() {
+		return &GameError{message: "You can only jump in with an identical card"}
+	}
+
+	return nil
+}
+
+// validateNoPendingChoice returns an error if the game is waiting on a player's choice
+func validateNoPendingChoice(game *Game) error {
+	if game.PendingChoice.Kind != NoChoice {
+		return &GameError{message: "Waiting for " + game.Players[game.PendingChoice.Player].Name + " to choose"}
+	}
+
+	return nil
+}
+
+// ChooseSwapTarget completes a 7 played under the Seven-O rule, swapping the player's hand with the
+// target player's hand
+func ChooseSwapTarget(game *Game, playerIndex int, targetIndex int) (*Game, error) {
+	if game.PendingChoice.Kind != SwapHandsChoice || game.PendingChoice.Player != playerIndex {
+		return game, &GameError{message: "You don't have a hand to swap"}
+	}
+
+	if targetIndex < 0 || targetIndex >= len(game.Players) || targetIndex == playerIndex || game.Players[targetIndex].Eliminated {
+		return game, &GameError{message: "Choose another player to swap hands with"}
+	}
+
+	// Whether UNO was called, or can still be caught, goes with the hand
+	player, target := &game.Players[playerIndex], &game.Players[targetIndex]
+	player.Cards, target.Cards = target.Cards, player.Cards
+	player.CalledUno, target.CalledUno = target.CalledUno, player.CalledUno
+	player.UnoVulnerable, target.UnoVulnerable = target.UnoVulnerable, player.UnoVulnerable
+
+	game.PendingChoice = PendingChoice{}
+
+	emit(game, Event{Kind: HandsSwappedEvent, Player: playerIndex, Target: targetIndex})
+
+	// Move to the next player
+	game = AdvancePlayer(game)
 
 	return game, nil
 }
 
+// RotateHands passes every player's hand to the next player in the current GameDirection. Whether UNO
+// was called on a hand, or can still be caught, is passed along with it.
+func RotateHands(game *Game) *Game {
+	rotated := make([]Player, len(game.Players))
+
+	for i, player := range game.Players {
+		if !player.Eliminated {
+			rotated[nextSeat(game, i)] = player
+		}
+	}
+
+	for i, player := range game.Players {
+		if !player.Eliminated {
+			game.Players[i].Cards = rotated[i].Cards
+			game.Players[i].CalledUno = rotated[i].CalledUno
+			game.Players[i].UnoVulnerable = rotated[i].UnoVulnerable
+		}
+	}
+
+	return game
+}
+
+// closeUnoWindow clears the UNO flags of any player who can no longer be caught, or who no longer holds
+// the card they called UNO on
+func closeUnoWindow(game *Game) *Game {
+	for i, player := range game.Players {
+		game.Players[i].UnoVulnerable = false
+
+		if len(player.Cards) > 2 {
+			game.Players[i].CalledUno = false
+		}
+	}
+
+	return game
+}
+
+// CallUno declares UNO for the player. It can be called when they're down to one card, or while it's
+// their turn and they're about to play their second to last card.
+func CallUno(game *Game, playerIndex int) (*Game, error) {
+	if game.State != GamePlaying {
+		return game, &GameError{message: "The game isn't being played"}
+	}
+
+	if !canCallUno(game, playerIndex) {
+		return game, &GameError{message: "You can't call UNO yet"}
+	}
+
+	game.Players[playerIndex].CalledUno = true
+	game.Players[playerIndex].UnoVulnerable = false
+
+	emit(game, Event{Kind: UnoCalledEvent, Player: playerIndex, Target: -1})
+
+	return game, nil
+}
+
+// canCallUno returns true if the player is down to one card, or it's their turn and they have two
+func canCallUno(game *Game, playerIndex int) bool {
+	numCards := len(game.Players[playerIndex].Cards)
+	return numCards == 1 || (numCards == 2 && game.ActivePlayer == playerIndex)
+}
+
+// CatchUno catches a player who went down to one card without calling UNO, who must then draw the
+// penalty cards
+func CatchUno(game *Game, catcherIndex int, targetIndex int) (*Game, error) {
+	if game.State != GamePlaying {
+		return game, &GameError{message: "The game isn't being played"}
+	}
+
+	if targetIndex < 0 || targetIndex >= len(game.Players) || targetIndex == catcherIndex {
+		return game, &GameError{message: "Choose another player to catch"}
+	}
+
+	if !game.Players[targetIndex].UnoVulnerable {
+		return game, &GameError{message: game.Players[targetIndex].Name + " can't be caught"}
+	}
+
+	game, penalty := Draw(game, game.Rules.UnoPenalty)
+	game.Players[targetIndex].Cards = append(game.Players[targetIndex].Cards, penalty...)
+	game.Players[targetIndex].UnoVulnerable = false
+	game.Players[targetIndex].CalledUno = false
+
+	emit(game, Event{Kind: UnoCaughtEvent, Player: catcherIndex, Target: targetIndex, Count: len(penalty)})
+
+	game = SortPlayerCards(game, targetIndex)
+
+	return game, nil
+}
+
+// currentColor returns the color that must be matched to play on the discard pile
+func currentColor(game *Game) Color {
+	topCard := game.DiscardPile[0]
+	if topCard.IsWild() {
+		return game.WildColor
+	}
+
+	return topCard.Color
+}
+
+func canChallenge(game *Game, playerIndex int) bool {
+	return game.WildDrawFour != nil &&
+		game.WildDrawFour.Victim == playerIndex &&
+		game.ActivePlayer == playerIndex &&
+		game.MustDraw >= 4
+}
+
+// ChallengeWildFour lets the victim of a wild+4 challenge it. If the player who played it held a card
+// of the color in play, they draw the 4 cards instead. Otherwise the challenger draws 2 extra.
+func ChallengeWildFour(game *Game, challengerIndex int) (*Game, error) {
+	if !canChallenge(game, challengerIndex) {
+		return game, &GameError{message: "There's no wild+4 for you to challenge"}
+	}
+
+	play := game.WildDrawFour
+	guilty := false
+
+	for _, card := range play.Hand {
+		if !card.IsWild() && card.Color == play.Color {
+			guilty = true
+		}
+	}
+
+	game.WildDrawFour = nil
+	game.Challenge = &ChallengeResult{
+		Challenger: challengerIndex,
+		Challenged: play.Player,
+		Guilty:     guilty,
+		Hand:       play.Hand,
+	}
+
+	emit(game, Event{Kind: ChallengedEvent, Player: challengerIndex, Target: play.Player, Guilty: guilty})
+
+	if !guilty {
+		game.MustDraw += 2
+		return game, nil
+	}
+
+	// The challenger is let off and carries on with their turn, while the offender draws instead
+	game.MustDraw -= 4
+
+	game, penalty := Draw(game, 4)
+	game.Players[play.Player].Cards = append(game.Players[play.Player].Cards, penalty...)
+	game.Players[play.Player].UnoVulnerable = false
+	game.Players[play.Player].CalledUno = false
+
+	return SortPlayerCards(game, play.Player), nil
+}
+
 func SortPlayerCards(game *Game, playerIndex int) *Game {
 	// Sort the new cards
 	sort.Slice(game.Players[playerIndex].Cards, func(a, b int) bool {
-		return game.Players[playerIndex].Cards[a] < game.Players[playerIndex].Cards[b]
+		return game.Players[playerIndex].Cards[a].String() < game.Players[playerIndex].Cards[b].String()
 	})
 
 	return game
 }
 
-// DrawCard draws a card from the DrawPile and places it in the active players hand
+// DrawCard draws a card from the DrawPile and places it in the active players hand. A player with a
+// draw penalty draws until it's paid, and otherwise how many cards they may draw depends on the
+// DrawMode rule.
 func DrawCard(game *Game) (*Game, error) {
 	playerIndex := game.ActivePlayer
 	currentCards := game.Players[playerIndex].Cards
 
+	if err := validateDrawCard(game); err != nil {
+		return game, err
+	}
+
 	// Draw one card from the deck
 	newGame, newCards := Draw(game, 1)
+
+	// Drawing accepts the wild+4, rather than challenging it
+	newGame.WildDrawFour = nil
 	newGame.Players[playerIndex].Cards = append(currentCards, newCards[0])
 
+	emit(newGame, Event{Kind: CardDrawnEvent, Player: playerIndex, Target: -1, Card: newCards[0]})
+
 	// Sort the player cards after adding
 	newGame = SortPlayerCards(game, playerIndex)
 
+	// Drawing takes the player back off UNO
+	if len(newGame.Players[playerIndex].Cards) > 2 {
+		newGame.Players[playerIndex].CalledUno = false
+	}
+	newGame.Players[playerIndex].UnoVulnerable = false
+
+	// Once the penalty has been drawn, the player's turn is over
 	if newGame.MustDraw > 0 {
 		newGame.MustDraw--
 
 		if newGame.MustDraw == 0 {
-			newGame = DoneDrawing(newGame)
+			newGame = AdvancePlayer(newGame)
 		}
+
+		return newGame, nil
 	}
 
+	newGame.HasDrawn = true
+	newGame.DrawnCard = newCards[0]
+
 	return newGame, nil
 }
 
+// validateDrawCard returns an error if the active player can't draw a card
+func validateDrawCard(game *Game) error {
+	if err := validateNoPendingChoice(game); err != nil {
+		return err
+	}
+
+	if game.MustDraw == 0 && game.HasDrawn {
+		if game.Rules.DrawMode == DrawSingle {
+			return &GameError{message: "You've already drawn a card this turn"}
+		}
+
+		if drawnCardPlayable(game) {
+			return &GameError{message: "You've already drawn a playable card"}
+		}
+	}
+
+	if !canDraw(game) {
+		return &GameError{message: "There are no cards left to draw"}
+	}
+
+	return nil
+}
+
+// drawnCardPlayable returns true if the card the active player drew this turn can be played
+func drawnCardPlayable(game *Game) bool {
+	return game.HasDrawn && ValidateCardPlay(game, game.DrawnCard) == nil
+}
+
+// canDraw returns true if there are any cards left to draw
+func canDraw(game *Game) bool {
+	return len(game.DrawPile) > 0 || len(game.DiscardPile) > 1
+}
+
 // DoneDrawing indicates that the current player is done drawing, and the next person should play
-func DoneDrawing(game *Game) *Game {
-	return AdvancePlayer(game)
+func DoneDrawing(game *Game) (*Game, error) {
+	if err := validateDoneDrawing(game); err != nil {
+		return game, err
+	}
+
+	emit(game, Event{Kind: TurnPassedEvent, Player: game.ActivePlayer, Target: -1})
+
+	return AdvancePlayer(game), nil
+}
+
+// validateDoneDrawing returns an error if the active player can't end their turn without playing
+func validateDoneDrawing(game *Game) error {
+	if err := validateNoPendingChoice(game); err != nil {
+		return err
+	}
+
+	if game.MustDraw > 0 {
+		return &GameError{message: "You need to draw your penalty cards first"}
+	}
+
+	// A player can only pass without drawing when there's nothing left to draw
+	if !game.HasDrawn && canDraw(game) {
+		return &GameError{message: "You need to draw a card before passing"}
+	}
+
+	if game.Rules.MustPlayDrawn && drawnCardPlayable(game) {
+		return &GameError{message: "You have to play the card you drew"}
+	}
+
+	if game.Rules.DrawMode == DrawUntilPlayable && game.HasDrawn && !drawnCardPlayable(game) && canDraw(game) {
+		return &GameError{message: "Keep drawing until you get a playable card"}
+	}
+
+	return nil
 }
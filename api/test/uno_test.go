@@ -3,60 +3,108 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"math/rand"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-test/deep"
 )
 
-func startedGame() Game {
-	rand.Seed(0)
-
-	game := EmptyGame()
+func startedGame() *Game {
+	game := EmptyGame("", "", 0)
 	game = AddPlayer(game, "Nia")
 	game = AddPlayer(game, "Eric")
 	game = DrawHands(game)
 
-	game = StartGame(game)
+	game, _ = StartGame(game)
 
 	return game
 }
 
-func printGame(game Game) {
+func printGame(game *Game) {
 	b, _ := json.Marshal(game)
 	fmt.Printf("%s\n", b)
 }
 
+// cards parses a list of cards written in the card notation
+func cards(names ...string) []Card {
+	parsed, err := ParseCards(names)
+	if err != nil {
+		panic(err)
+	}
+
+	return parsed
+}
+
+func TestParseCard(t *testing.T) {
+	names := []string{"R0", "G9", "Bskip", "Yrev", "R+2", "wild", "wild+4"}
+
+	for _, name := range names {
+		card, err := ParseCard(name)
+		if err != nil {
+			t.Error(fmt.Sprintf("Didn't expect an error parsing %s: %s", name, err))
+		}
+
+		if card.String() != name {
+			t.Error(fmt.Sprintf("Expected %s to be written as %s, got %s", name, name, card.String()))
+		}
+	}
+
+	for _, name := range []string{"", "R", "R10", "Pskip", "Rwild", "wild+2"} {
+		if _, err := ParseCard(name); err == nil {
+			t.Error(fmt.Sprintf("Expected an error parsing %q", name))
+		}
+	}
+
+	if NumberCard(Red, 5).Points() != 5 || ActionCard(Green, DrawTwo).Points() != 20 || WildCard(Wild).Points() != 50 {
+		t.Error("Expected cards to be worth their face value, 20 for actions and 50 for wilds")
+	}
+}
+
 func TestEmptyGame(t *testing.T) {
-	rand.Seed(0)
+	game := EmptyGame("", "", 0)
 
-	game := EmptyGame()
+	if game.State != GameCreated || len(game.Players) != 0 || len(game.DiscardPile) != 0 {
+		t.Error("Expected an empty game in the lobby")
+	}
 
-	expected := Game{
-		State:         GameCreated,
-		Players:       []Player{},
-		ActivePlayer:  0,
-		GameDirection: 1,
-		DrawPile:      []string{"B4", "R4", "B0", "G+2", "R5", "Yrev", "G6", "R1", "R+2", "R3", "B8", "Y+2", "Gskip", "Y8", "Grev", "G8", "R+2", "Y2", "Yskip", "wild", "wild", "R0", "B6", "B2", "Y9", "R3", "B9", "wild+4", "Bskip", "B+2", "R6", "wild+4", "R9", "wild", "B5", "G2", "R4", "G7", "G2", "R6", "Grev", "R9", "B8", "Y3", "B3", "Y0", "B5", "B1", "Yskip", "B1", "Y1", "G5", "G8", "B9", "B3", "Y1", "Y4", "R2", "B6", "Y+2", "B2", "Y7", "wild+4", "Bskip", "G5", "Gskip", "R7", "G1", "Y6", "Y2", "Yrev", "G+2", "wild+4", "Y5", "Y4", "R2", "Rskip", "R8", "G9", "B4", "Y8", "G7", "Y9", "R8", "Rrev", "G9", "G3", "G6", "Y7", "Brev", "R1", "B+2", "Rskip", "B7", "G0", "Y6", "Y3", "G3", "R7", "Y5", "B7", "Rrev", "G4", "G4", "R5", "Brev", "G1", "wild"},
-		DiscardPile:   []string{},
+	expectedDrawPile := cards("G9", "B9", "wild+4", "B9", "Y+2", "Y5", "Y3", "G6", "Bskip", "Y6", "Y1", "B5", "G3", "B4", "G1", "B+2", "R5", "Rrev", "Y2", "B6", "Yskip", "wild+4", "B3", "R8", "G4", "R6", "B6", "Brev", "R3", "wild", "Rskip", "Y9", "G3", "Y8", "Y6", "R4", "G6", "B8", "Yskip", "G9", "R+2", "R1", "Grev", "B5", "G5", "wild", "B2", "Y7", "G2", "Y7", "G7", "R9", "G7", "G8", "R0", "B3", "Gskip", "B4", "R7", "Y9", "G0", "R6", "B8", "B1", "R4", "wild+4", "R5", "G1", "R+2", "Y8", "Y1", "Y5", "G5", "B1", "R1", "R3", "R7", "Y3", "Y+2", "wild+4", "Rrev", "wild", "G+2", "B+2", "G2", "Y4", "B7", "Y4", "Y0", "R9", "Brev", "Grev", "Gskip", "B7", "B2", "B0", "Bskip", "G4", "Yrev", "Rskip", "Y2", "R8", "G8", "R2", "wild", "R2", "G+2", "Yrev")
+
+	if diff := deep.Equal(game.DrawPile, expectedDrawPile); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestSeededGames(t *testing.T) {
+	play := func(seed int64) *Game {
+		game := EmptyGame("", "", seed)
+		game = AddPlayer(game, "Nia")
+		game = AddPlayer(game, "Eric")
+
+		game, _, _ = Apply(game, Action{Kind: StartAction})
+		game, _, _ = Apply(game, Action{Kind: DrawAction, Player: game.ActivePlayer})
+
+		return game
 	}
 
-	if diff := deep.Equal(game, expected); diff != nil {
+	if diff := deep.Equal(play(42), play(42)); diff != nil {
 		t.Error(diff)
 	}
+
+	if deep.Equal(play(42).Players, play(43).Players) == nil {
+		t.Error("Expected games with different seeds to be dealt differently")
+	}
 }
 
 func TestAddPlayer(t *testing.T) {
-	rand.Seed(0)
-
-	game := EmptyGame()
+	game := EmptyGame("", "", 0)
 
 	game = AddPlayer(game, "Nia")
 	game = AddPlayer(game, "Eric")
 
 	expected := []Player{
-		Player{Name: "Nia", Cards: []string{}},
-		Player{Name: "Eric", Cards: []string{}},
+		Player{Name: "Nia", Cards: cards(), Team: 0, RoundScores: []int{}},
+		Player{Name: "Eric", Cards: cards(), Team: 1, RoundScores: []int{}},
 	}
 
 	if diff := deep.Equal(game.Players, expected); diff != nil {
@@ -65,9 +113,7 @@ func TestAddPlayer(t *testing.T) {
 }
 
 func TestDrawHands(t *testing.T) {
-	rand.Seed(0)
-
-	game := EmptyGame()
+	game := EmptyGame("", "", 0)
 
 	game = AddPlayer(game, "Nia")
 	game = AddPlayer(game, "Eric")
@@ -75,11 +121,11 @@ func TestDrawHands(t *testing.T) {
 	game = DrawHands(game)
 
 	expectedPlayers := []Player{
-		Player{Name: "Nia", Cards: []string{"B4", "R4", "B0", "G+2", "R5", "Yrev", "G6"}},
-		Player{Name: "Eric", Cards: []string{"R1", "R+2", "R3", "B8", "Y+2", "Gskip", "Y8"}},
+		Player{Name: "Nia", Cards: cards("B4", "G2", "G9", "R1", "R2", "R3", "Y5"), Team: 0, RoundScores: []int{}},
+		Player{Name: "Eric", Cards: cards("B3", "G4", "R0", "R1", "Rrev", "Y2", "Yrev"), Team: 1, RoundScores: []int{}},
 	}
 
-	expectedDiscard := []string{"Grev"}
+	expectedDiscard := cards("Y7")
 
 	if diff := deep.Equal(game.Players, expectedPlayers); diff != nil {
 		t.Error(diff)
@@ -102,6 +148,30 @@ func TestStartGame(t *testing.T) {
 	}
 }
 
+func TestStartGameDeckTooSmall(t *testing.T) {
+	game := EmptyGame("", "", 0)
+	for _, name := range []string{"0", "1", "2", "3", "4", "5"} {
+		game = AddPlayer(game, name)
+	}
+	game.Rules.HandSize = 20
+
+	// 6 hands of 20 cards need more than the 108 in the deck
+	if _, _, err := Apply(game, Action{Kind: StartAction}); err == nil {
+		t.Error("Expected an error dealing more cards than the deck holds")
+	}
+
+	game.Rules.HandSize = 17
+
+	next, _, err := Apply(game, Action{Kind: StartAction})
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if !(next.State == GamePlaying && len(next.Players[5].Cards) == 17) {
+		t.Error("Expected every player to be dealt 17 cards")
+	}
+}
+
 func TestPlayCard(t *testing.T) {
 	game := startedGame()
 
@@ -111,15 +181,13 @@ func TestPlayCard(t *testing.T) {
 		t.Error("Should not have returned error")
 	}
 
-	expectedPlayerCards := []string{
-		"B4", "R4", "B0", "G+2", "R5", "Yrev",
-	}
+	expectedPlayerCards := cards("B4", "G2", "G9", "R1", "R2", "R3")
 	if diff := deep.Equal(game.Players[0].Cards, expectedPlayerCards); diff != nil {
 		t.Error(diff)
 	}
 
-	if game.DiscardPile[0] != "G6" {
-		t.Error("Top of discard pile should be G6")
+	if game.DiscardPile[0].String() != "Y5" {
+		t.Error("Top of discard pile should be Y5")
 	}
 }
 
@@ -178,7 +246,13 @@ func TestValidateCardPlay(t *testing.T) {
 	}
 
 	for _, play := range plays {
-		err := ValidateCardPlay(play.topCard, play.wildColor, play.newCard)
+		game := &Game{
+			DiscardPile: cards(play.topCard),
+			WildColor:   Color(play.wildColor),
+			Rules:       DefaultRuleSet(),
+		}
+
+		err := ValidateCardPlay(game, cards(play.newCard)[0])
 
 		if play.valid && err != nil {
 			t.Error(fmt.Sprintf("Expected %s played on %s (%s) to be valid", play.newCard, play.topCard, play.wildColor))
@@ -190,20 +264,46 @@ func TestValidateCardPlay(t *testing.T) {
 	}
 }
 
+func TestUpdateSettings(t *testing.T) {
+	game := EmptyGame("ABCD", "", 0)
+
+	game, err := UpdateSettings(game, map[string]string{"handSize": "5", "twoPlayerReverseSkip": "false"})
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if game.Rules.HandSize != 5 || game.Rules.TwoPlayerReverseSkip {
+		t.Error("Expected the settings to be applied")
+	}
+
+	if _, err = UpdateSettings(game, map[string]string{"handSize": "0"}); err == nil {
+		t.Error("Expected an error for an out of range hand size")
+	}
+
+	if _, err = UpdateSettings(game, map[string]string{"noSuchRule": "true"}); err == nil {
+		t.Error("Expected an error for an unknown setting")
+	}
+
+	game.State = GamePlaying
+	if _, err = UpdateSettings(game, map[string]string{"handSize": "7"}); err == nil {
+		t.Error("Expected an error changing settings after the game started")
+	}
+}
+
 func TestAdvancePlayerPlusTwo(t *testing.T) {
 
-	game := Game{
+	game := &Game{
 		State: GameCreated,
 		Players: []Player{
-			Player{Name: "0", Cards: []string{"R+2"}},
-			Player{Name: "1", Cards: []string{"R+2"}},
-			Player{Name: "2", Cards: []string{"R+2"}},
+			Player{Name: "0", Cards: cards("R+2", "B5")},
+			Player{Name: "1", Cards: cards("R+2", "B5")},
+			Player{Name: "2", Cards: cards("R+2", "B5")},
 		},
 		ActivePlayer:  0,
 		MustDraw:      0,
 		GameDirection: Clockwise,
-		DrawPile:      []string{"B4", "R4", "B0", "G+2"},
-		DiscardPile:   []string{"R0"},
+		DrawPile:      cards("B4", "R4", "B0", "G+2"),
+		DiscardPile:   cards("R0"),
 	}
 
 	// Play a +2
@@ -231,18 +331,18 @@ func TestAdvancePlayerPlusTwo(t *testing.T) {
 
 func TestAdvancePlayerPlusFour(t *testing.T) {
 
-	game := Game{
+	game := &Game{
 		State: GameCreated,
 		Players: []Player{
-			Player{Name: "0", Cards: []string{"R+4"}},
-			Player{Name: "1", Cards: []string{"R+4"}},
-			Player{Name: "2", Cards: []string{"R+4"}},
+			Player{Name: "0", Cards: cards("wild+4", "B5")},
+			Player{Name: "1", Cards: cards("wild+4", "B5")},
+			Player{Name: "2", Cards: cards("wild+4", "B5")},
 		},
 		ActivePlayer:  0,
 		MustDraw:      0,
 		GameDirection: Clockwise,
-		DrawPile:      []string{"B4", "R4", "B0", "G+2"},
-		DiscardPile:   []string{"R0"},
+		DrawPile:      cards("B4", "R4", "B0", "G+2"),
+		DiscardPile:   cards("R0"),
 	}
 
 	// Play a +4
@@ -270,20 +370,1454 @@ func TestAdvancePlayerPlusFour(t *testing.T) {
 	}
 }
 
+func TestStackDrawPenalties(t *testing.T) {
+
+	game := &Game{
+		State: GamePlaying,
+		Players: []Player{
+			Player{Name: "0", Cards: cards("R+2", "B5")},
+			Player{Name: "1", Cards: cards("G+2", "Y1")},
+			Player{Name: "2", Cards: cards("wild+4", "R1")},
+		},
+		ActivePlayer:  0,
+		MustDraw:      0,
+		GameDirection: Clockwise,
+		DrawPile:      cards("B4", "R4", "B0", "G+2", "Y1", "Y2", "Y3", "Y4", "Y5"),
+		DiscardPile:   cards("R0"),
+		Rules:         DefaultRuleSet(),
+	}
+	game.Rules.StackDrawTwo = true
+	game.Rules.StackDrawFour = true
+
+	// Play a +2, and answer it with another +2
+	game, err := PlayCard(game, 0, "")
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	game, err = PlayCard(game, 0, "")
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if !(game.ActivePlayer == 2 && game.MustDraw == 4) {
+		t.Error("Expected player 2 to be active with 4 cards to draw")
+	}
+
+	// Only a draw card can be stacked
+	game, err = PlayCard(game, 1, "")
+	if err == nil {
+		t.Error("Expected an error. The player must draw")
+	}
+
+	// Stack a +4 on top
+	game, err = PlayCard(game, 0, "B")
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if !(game.ActivePlayer == 0 && game.MustDraw == 8) {
+		t.Error("Expected player 0 to be active with 8 cards to draw")
+	}
+}
+
+func TestJumpIn(t *testing.T) {
+
+	game := &Game{
+		State: GamePlaying,
+		Players: []Player{
+			Player{Name: "0", Cards: cards("R5", "B5")},
+			Player{Name: "1", Cards: cards("G+2", "Y1")},
+			Player{Name: "2", Cards: cards("R5", "R1")},
+			Player{Name: "3", Cards: cards("R5", "R1")},
+		},
+		ActivePlayer:  0,
+		GameDirection: Clockwise,
+		DrawPile:      cards("B4", "R4", "B0"),
+		DiscardPile:   cards("R0"),
+		Rules:         DefaultRuleSet(),
+	}
+	game.Rules.JumpIn = true
+
+	game, err := PlayCard(game, 0, "")
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	// Player 2 jumps in on player 0's R5, skipping player 1
+	seenCount := len(game.DiscardPile)
+	game, err = JumpIn(game, 2, 0, seenCount)
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if game.ActivePlayer != 3 {
+		t.Error("Expected player 3 to be active")
+	}
+
+	// Player 3 tried to jump in on the same card, but was too slow
+	game, err = JumpIn(game, 3, 0, seenCount)
+	if err == nil {
+		t.Error("Expected an error. Another player jumped in first")
+	}
+
+	// Only identical cards can jump in
+	game, err = JumpIn(game, 3, 1, len(game.DiscardPile))
+	if err == nil {
+		t.Error("Expected an error. The card isn't identical")
+	}
+}
+
+func TestSevenO(t *testing.T) {
+
+	game := &Game{
+		State: GamePlaying,
+		Players: []Player{
+			Player{Name: "0", Cards: cards("R7", "B5", "B6")},
+			Player{Name: "1", Cards: cards("R0", "Y1")},
+			Player{Name: "2", Cards: cards("G1")},
+		},
+		ActivePlayer:  0,
+		GameDirection: Clockwise,
+		DrawPile:      cards("B4", "R4", "B0"),
+		DiscardPile:   cards("R2"),
+		Rules:         DefaultRuleSet(),
+	}
+	game.Rules.SevenO = true
+
+	// Play a 7, which waits for the player to choose who to swap with
+	game, err := PlayCard(game, 0, "")
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if !(game.ActivePlayer == 0 && game.PendingChoice.Kind == SwapHandsChoice) {
+		t.Error("Expected player 0 to be choosing a player to swap with")
+	}
+
+	game, err = ChooseSwapTarget(game, 0, 2)
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if diff := deep.Equal(game.Players[0].Cards, cards("G1")); diff != nil {
+		t.Error(diff)
+	}
+
+	if diff := deep.Equal(game.Players[2].Cards, cards("B5", "B6")); diff != nil {
+		t.Error(diff)
+	}
+
+	// Play a 0, which passes every hand to the next player
+	game, err = PlayCard(game, 0, "")
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if diff := deep.Equal(game.Players[0].Cards, cards("B5", "B6")); diff != nil {
+		t.Error(diff)
+	}
+
+	if diff := deep.Equal(game.Players[1].Cards, cards("G1")); diff != nil {
+		t.Error(diff)
+	}
+
+	if diff := deep.Equal(game.Players[2].Cards, cards("Y1")); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestSevenOUno(t *testing.T) {
+
+	game := &Game{
+		State: GamePlaying,
+		Players: []Player{
+			Player{Name: "0", Cards: cards("R7", "B5")},
+			Player{Name: "1", Cards: cards("R0", "Y1", "Y2", "Y3", "Y4")},
+			Player{Name: "2", Cards: cards("G1", "G2")},
+		},
+		ActivePlayer:  0,
+		GameDirection: Clockwise,
+		DrawPile:      cards("B4", "R4", "B0", "B1"),
+		DiscardPile:   cards("R2"),
+		Rules:         DefaultRuleSet(),
+	}
+	game.Rules.SevenO = true
+
+	// Player 0 goes down to one card without calling UNO, then swaps that card away
+	game, err := PlayCard(game, 0, "")
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	game, err = ChooseSwapTarget(game, 0, 1)
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if _, err := CatchUno(game, 2, 0); err == nil {
+		t.Error("Expected an error. Player 0 no longer holds one card")
+	}
+
+	game, err = CatchUno(game, 2, 1)
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if len(game.Players[1].Cards) != 3 {
+		t.Error("Expected player 1 to draw 2 cards")
+	}
+
+	// A 0 passes the hand along with it, so whoever ends up holding one card can be caught
+	game.Players[0].Cards = cards("B6")
+	game.Players[1].Cards = cards("B0", "G5")
+	game.Players[2].Cards = cards("R3", "R4")
+	game.Players[2].UnoVulnerable = false
+	game.ActivePlayer = 1
+	game.DiscardPile = cards("B2")
+
+	game, err = PlayCard(game, 0, "")
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if !(game.Players[2].UnoVulnerable && !game.Players[1].UnoVulnerable) {
+		t.Error("Expected player 2 to be vulnerable, holding the hand player 1 went down to one card with")
+	}
+
+	if game.Players[1].CalledUno {
+		t.Error("Expected player 1 not to have called UNO")
+	}
+}
+
+func TestCatchUno(t *testing.T) {
+
+	game := &Game{
+		State: GamePlaying,
+		Players: []Player{
+			Player{Name: "0", Cards: cards("R7", "B5")},
+			Player{Name: "1", Cards: cards("R0", "Y1")},
+			Player{Name: "2", Cards: cards("G1", "G2", "G3")},
+		},
+		ActivePlayer:  0,
+		GameDirection: Clockwise,
+		DrawPile:      cards("B4", "R4", "B0", "B1"),
+		DiscardPile:   cards("R2"),
+		Rules:         DefaultRuleSet(),
+	}
+
+	// Player 0 goes down to one card without calling UNO
+	game, err := PlayCard(game, 0, "")
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if !game.Players[0].UnoVulnerable {
+		t.Error("Expected player 0 to be vulnerable")
+	}
+
+	// Player 1 calls UNO before playing their second to last card
+	game, err = CallUno(game, 1)
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	// Player 2 catches player 0
+	game, err = CatchUno(game, 2, 0)
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if len(game.Players[0].Cards) != 3 {
+		t.Error("Expected player 0 to draw 2 cards")
+	}
+
+	// Player 1 called UNO, so they can't be caught
+	game, err = PlayCard(game, 0, "")
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	game, err = CatchUno(game, 2, 1)
+	if err == nil {
+		t.Error("Expected an error. Player 1 called UNO")
+	}
+}
+
+func TestChallengeWildFour(t *testing.T) {
+
+	game := &Game{
+		State: GamePlaying,
+		Players: []Player{
+			Player{Name: "0", Cards: cards("B5", "R7", "wild+4")},
+			Player{Name: "1", Cards: cards("R0", "Y1")},
+		},
+		ActivePlayer:  0,
+		GameDirection: Clockwise,
+		DrawPile:      cards("B4", "R4", "B0", "B1", "Y2", "Y3"),
+		DiscardPile:   cards("R2"),
+		Rules:         DefaultRuleSet(),
+	}
+
+	// Play a wild+4 while holding a red card
+	game, err := PlayCard(game, 2, "G")
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	game, err = ChallengeWildFour(game, 1)
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if !(game.Challenge.Guilty && game.MustDraw == 0 && game.ActivePlayer == 1) {
+		t.Error("Expected the challenge to succeed, and player 1 to carry on")
+	}
+
+	if len(game.Players[0].Cards) != 6 {
+		t.Error("Expected player 0 to draw 4 cards")
+	}
+
+	// Only the challenger sees the hand
+	if GetPlayersGame(game, 0).Challenge.Hand != nil {
+		t.Error("Expected the hand to be hidden from player 0")
+	}
+
+	if diff := deep.Equal(GetPlayersGame(game, 1).Challenge.Hand, cards("B5", "R7")); diff != nil {
+		t.Error(diff)
+	}
+
+	// A wild+4 stacked on a +2 can't be challenged
+	game = &Game{
+		State: GamePlaying,
+		Players: []Player{
+			Player{Name: "0", Cards: cards("B5", "R7", "wild+4")},
+			Player{Name: "1", Cards: cards("R0", "Y1")},
+		},
+		ActivePlayer:  0,
+		GameDirection: Clockwise,
+		MustDraw:      2,
+		DrawPile:      cards("B4", "R4", "B0", "B1", "Y2", "Y3"),
+		DiscardPile:   cards("R+2"),
+		Rules:         DefaultRuleSet(),
+	}
+	game.Rules.StackDrawFour = true
+
+	game, err = PlayCard(game, 2, "R")
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if _, err := ChallengeWildFour(game, 1); err == nil || game.MustDraw != 6 {
+		t.Error("Expected the stacked wild+4 not to be challengeable")
+	}
+}
+
+func TestScoreRound(t *testing.T) {
+
+	game := &Game{
+		State: GamePlaying,
+		Players: []Player{
+			Player{Name: "0", Cards: cards("R7"), RoundScores: []int{}},
+			Player{Name: "1", Cards: cards("R0", "Y9", "Gskip"), RoundScores: []int{}},
+			Player{Name: "2", Cards: cards("wild", "B+2"), RoundScores: []int{}},
+		},
+		ActivePlayer:  0,
+		GameDirection: Clockwise,
+		DrawPile:      cards("B4", "R4", "B0"),
+		DiscardPile:   cards("R2"),
+		Rules:         DefaultRuleSet(),
+	}
+	game.Rules.TargetScore = 200
+
+	game, err := PlayCard(game, 0, "")
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	// 0 + 9 + 20 + 50 + 20
+	if !(game.State == RoundComplete && game.RoundWinner == 0 && game.Players[0].Score == 99) {
+		t.Error("Expected player 0 to win the round with 99 points")
+	}
+
+	if diff := deep.Equal(game.Players[1].RoundScores, []int{0}); diff != nil {
+		t.Error(diff)
+	}
+
+	// Reaching the target score wins the match
+	game.Players[0].Score = 150
+	game = ScoreRound(game, 0)
+
+	if !(game.State == GameComplete && game.MatchWinner == 0) {
+		t.Error("Expected player 0 to win the match")
+	}
+}
+
+func TestDrawUntilPlayable(t *testing.T) {
+
+	game := &Game{
+		State: GamePlaying,
+		Players: []Player{
+			Player{Name: "0", Cards: cards("B5", "G9")},
+			Player{Name: "1", Cards: cards("R0", "Y1")},
+		},
+		ActivePlayer:  0,
+		GameDirection: Clockwise,
+		DrawPile:      cards("B4", "Y4", "R4", "B1"),
+		DiscardPile:   cards("R2"),
+		Rules:         DefaultRuleSet(),
+	}
+	game.Rules.DrawMode = DrawUntilPlayable
+	game.Rules.MustPlayDrawn = true
+
+	// Players can't pass without drawing
+	game, err := DoneDrawing(game)
+	if err == nil {
+		t.Error("Expected an error. The player must draw first")
+	}
+
+	// Keep drawing until the R4 turns up
+	game, err = DrawCard(game)
+	game, err = DoneDrawing(game)
+	if err == nil {
+		t.Error("Expected an error. The player must keep drawing")
+	}
+
+	game, err = DrawCard(game)
+	game, err = DrawCard(game)
+	if game.DrawnCard.String() != "R4" {
+		t.Error("Expected player 0 to have drawn the R4")
+	}
+
+	game, err = DrawCard(game)
+	if err == nil {
+		t.Error("Expected an error. The player already drew a playable card")
+	}
+
+	game, err = DoneDrawing(game)
+	if err == nil {
+		t.Error("Expected an error. The player must play the card they drew")
+	}
+}
+
+func TestPlayCards(t *testing.T) {
+
+	game := &Game{
+		State: GamePlaying,
+		Players: []Player{
+			Player{Name: "0", Cards: cards("B5", "G5", "R5", "R6")},
+			Player{Name: "1", Cards: cards("R0", "Y1")},
+		},
+		ActivePlayer:  0,
+		GameDirection: Clockwise,
+		DrawPile:      cards("B4", "Y4", "R4", "B1"),
+		DiscardPile:   cards("R2"),
+		Rules:         DefaultRuleSet(),
+	}
+	game.Rules.MultiPlay = true
+
+	// The first card must be playable on the R2
+	game, err := PlayCards(game, []int{0, 2}, "")
+	if err == nil {
+		t.Error("Expected an error. B5 can't be played on R2")
+	}
+
+	// All the cards must have the same number
+	game, err = PlayCards(game, []int{2, 3}, "")
+	if err == nil {
+		t.Error("Expected an error. The cards have different numbers")
+	}
+
+	game, err = PlayCards(game, []int{2, 1, 0}, "")
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if diff := deep.Equal(game.DiscardPile, cards("B5", "G5", "R5", "R2")); diff != nil {
+		t.Error(diff)
+	}
+
+	if diff := deep.Equal(game.Players[0].Cards, cards("R6")); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestTeamScoring(t *testing.T) {
+
+	game := &Game{
+		State: GamePlaying,
+		Players: []Player{
+			Player{Name: "0", Cards: cards("R7"), Team: 0, RoundScores: []int{}},
+			Player{Name: "1", Cards: cards("R0", "Y9"), Team: 1, RoundScores: []int{}},
+			Player{Name: "2", Cards: cards("wild"), Team: 0, Score: 30, RoundScores: []int{}},
+			Player{Name: "3", Cards: cards("Bskip"), Team: 1, RoundScores: []int{}},
+		},
+		ActivePlayer:  0,
+		GameDirection: Clockwise,
+		DrawPile:      cards("B4", "R4", "B0"),
+		DiscardPile:   cards("R2"),
+		Rules:         DefaultRuleSet(),
+	}
+	game.Rules.Teams = true
+	game.Rules.TargetScore = 50
+
+	if PartnerIndex(game, 1) != 3 {
+		t.Error("Expected player 3 to be player 1's partner")
+	}
+
+	game, err := PlayCard(game, 0, "")
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	// Only the other team's cards count: 0 + 9 + 20
+	if game.Players[0].Score != 29 {
+		t.Error("Expected player 0 to score 29 points")
+	}
+
+	if !(game.State == GameComplete && game.WinningTeam == 0 && TeamScore(game, 0) == 59) {
+		t.Error("Expected team 0 to win the match")
+	}
+}
+
+func TestElimination(t *testing.T) {
+
+	game := &Game{
+		State: GamePlaying,
+		Players: []Player{
+			Player{Name: "0", Cards: cards("R2"), RoundScores: []int{}},
+			Player{Name: "1", Cards: cards("R5"), RoundScores: []int{}},
+			Player{Name: "2", Cards: cards("wild"), RoundScores: []int{}},
+		},
+		ActivePlayer:  0,
+		GameDirection: Clockwise,
+		DrawPile:      cards("B4", "R4", "B0"),
+		DiscardPile:   cards("R1"),
+		Rules:         DefaultRuleSet(),
+	}
+	game.Rules.Elimination = true
+
+	// Player 2 is left holding the most points
+	game, err := PlayCard(game, 0, "")
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if !(game.State == RoundComplete && game.Players[2].Eliminated) {
+		t.Error("Expected player 2 to be eliminated")
+	}
+
+	// Nobody can catch anyone between rounds, and eliminated players can't make moves
+	game.Players[1].UnoVulnerable = true
+
+	if _, err := CatchUno(cloneGame(game), 0, 1); err == nil {
+		t.Error("Expected an error catching a player between rounds")
+	}
+
+	game.State = GamePlaying
+
+	if _, _, err := Apply(game, Action{Kind: CatchUnoAction, Player: 2, Target: 1}); err == nil {
+		t.Error("Expected an error catching a player while eliminated")
+	}
+
+	game.State = RoundComplete
+	game.Players[1].UnoVulnerable = false
+
+	// Eliminated players are passed over
+	game.ActivePlayer = 1
+	game = AdvancePlayer(game)
+
+	if game.ActivePlayer != 0 {
+		t.Error("Expected player 0 to be active")
+	}
+
+	// Eliminating player 1 leaves player 0 as the winner
+	game.Players[1].Cards = cards("R5")
+	game = ScoreRound(game, 0)
+
+	if !(game.State == GameComplete && game.MatchWinner == 0) {
+		t.Error("Expected player 0 to win the match")
+	}
+
+	// The round winner is never knocked out, and ties go to the player holding more cards, then to
+	// whoever sits first after the winner
+	game = &Game{
+		State: RoundComplete,
+		Players: []Player{
+			Player{Name: "0", Cards: cards("R0")},
+			Player{Name: "1", Cards: cards()},
+			Player{Name: "2", Cards: cards("R0", "B0")},
+			Player{Name: "3", Cards: cards("Y0", "G0")},
+		},
+		RoundWinner: 1,
+		Rules:       DefaultRuleSet(),
+	}
+
+	game = eliminatePlayer(game)
+
+	if !game.Players[2].Eliminated || game.Players[1].Eliminated || game.Players[3].Eliminated {
+		t.Error("Expected player 2 to be eliminated")
+	}
+}
+
+func TestStartingCard(t *testing.T) {
+
+	game := &Game{
+		State: GameCreated,
+		Players: []Player{
+			Player{Name: "0", Cards: cards("R2")},
+			Player{Name: "1", Cards: cards("R5")},
+			Player{Name: "2", Cards: cards("G1")},
+		},
+		ActivePlayer:  0,
+		GameDirection: Clockwise,
+		DrawPile:      cards("B4", "R4", "B0"),
+		DiscardPile:   cards("Rskip"),
+		Rules:         DefaultRuleSet(),
+	}
+
+	// A skip turned over skips the first player
+	game = applyStartingCard(game)
+	if game.ActivePlayer != 1 {
+		t.Error("Expected player 1 to be active")
+	}
+
+	// A wild turned over lets the first player choose the color
+	game.Rules.ChooseStartingColor = true
+	game.DiscardPile = cards("wild")
+	game = applyStartingCard(game)
+
+	if !(game.PendingChoice.Kind == StartingColorChoice && game.PendingChoice.Player == 1) {
+		t.Error("Expected player 1 to be choosing the starting color")
+	}
+
+	game, err := ChooseStartingColor(game, 1, "B")
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if !(game.WildColor == "B" && game.ActivePlayer == 1) {
+		t.Error("Expected player 1 to play on blue")
+	}
+}
+
+func TestLegalMoves(t *testing.T) {
+	game := &Game{
+		State: GamePlaying,
+		Players: []Player{
+			Player{Name: "0", Cards: cards("R5", "B7", "wild", "B2")},
+			Player{Name: "1", Cards: cards("G4", "R2")},
+			Player{Name: "2", Cards: cards("Y1", "Y2")},
+		},
+		ActivePlayer:  0,
+		GameDirection: Clockwise,
+		DrawPile:      cards("B4", "R4", "B0"),
+		DiscardPile:   cards("R2"),
+		Rules:         DefaultRuleSet(),
+	}
+	game.Rules.JumpIn = true
+
+	moves := GetLegalMoves(game, 0)
+
+	if diff := deep.Equal(moves.PlayableCards, []int{0, 2, 3}); diff != nil {
+		t.Error(diff)
+	}
+
+	if len(moves.WildColors) != 4 || !moves.CanDraw || moves.CanDoneDrawing {
+		t.Error("Expected player 0 to be able to play a wild of any color or draw, but not pass")
+	}
+
+	moves = GetLegalMoves(game, 1)
+
+	if len(moves.PlayableCards) != 0 || moves.CanDraw {
+		t.Error("Expected player 1 not to be able to play or draw out of turn")
+	}
+
+	if diff := deep.Equal(moves.JumpInCards, []int{1}); diff != nil {
+		t.Error(diff)
+	}
+
+	if _, err := PlayCard(game, 4, ""); err == nil {
+		t.Error("Expected an error playing a card that isn't in the hand")
+	}
+
+	game, err := DrawCard(game)
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	moves = GetLegalMoves(game, 0)
+
+	if moves.CanDraw || !moves.CanDoneDrawing {
+		t.Error("Expected player 0 to be able to pass, but not draw again")
+	}
+
+	game, err = DoneDrawing(game)
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if diff := deep.Equal(GetPlayersGame(game, 1).LegalMoves.PlayableCards, []int{1}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestApply(t *testing.T) {
+	game := &Game{
+		State: GamePlaying,
+		Players: []Player{
+			Player{Name: "0", Cards: cards("Rskip", "B7")},
+			Player{Name: "1", Cards: cards("G4", "R2")},
+			Player{Name: "2", Cards: cards("Y1", "Y2")},
+		},
+		ActivePlayer:  0,
+		GameDirection: Clockwise,
+		DrawPile:      cards("B4", "R4", "B0"),
+		DiscardPile:   cards("R2"),
+		Rules:         DefaultRuleSet(),
+	}
+
+	if _, _, err := Apply(game, Action{Kind: PlayAction, Player: 1, Cards: []int{1}}); err == nil {
+		t.Error("Expected an error playing out of turn")
+	}
+
+	if _, _, err := Apply(game, Action{Kind: StartAction}); err == nil {
+		t.Error("Expected an error starting a game that's being played")
+	}
+
+	next, events, err := Apply(game, Action{Kind: PlayAction, Player: 0, Cards: []int{0}})
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if len(game.Players[0].Cards) != 2 || len(game.DiscardPile) != 1 || game.ActivePlayer != 0 {
+		t.Error("Expected the original game to be left untouched")
+	}
+
+	if next.ActivePlayer != 2 {
+		t.Error("Expected player 2 to be active after the skip")
+	}
+
+	expected := []Event{
+		Event{Kind: CardPlayedEvent, Player: 0, Target: -1, Card: cards("Rskip")[0]},
+		Event{Kind: PlayerSkippedEvent, Player: 1, Target: -1},
+	}
+	if diff := deep.Equal(events, expected); diff != nil {
+		t.Error(diff)
+	}
+
+	next, events, err = Apply(next, Action{Kind: DrawAction, Player: 2})
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if len(events) != 1 || events[0].Kind != CardDrawnEvent || events[0].Card.String() != "B4" {
+		t.Error("Expected player 2 to draw the B4")
+	}
+
+	if GetPlayersGame(next, 2).Events[0].Card.String() != "B4" || GetPlayersGame(next, 0).Events[0].Card != (Card{}) {
+		t.Error("Expected the drawn card to only be shown to the player who drew it")
+	}
+}
+
+func TestHideReplayCards(t *testing.T) {
+	replay := &Replay{
+		Log: []ReplayEntry{
+			ReplayEntry{
+				Verb: "startGame",
+				Deal: &Deal{Hands: [][]Card{cards("R1"), cards("B2")}},
+			},
+			ReplayEntry{
+				Verb: "drawCard",
+				Events: []Event{
+					Event{Kind: CardDrawnEvent, Player: 0, Target: -1, Card: cards("G5")[0]},
+				},
+			},
+			ReplayEntry{
+				Verb: "playCard",
+				Events: []Event{
+					Event{Kind: CardPlayedEvent, Player: 1, Target: -1, Card: cards("B2")[0]},
+				},
+			},
+		},
+	}
+
+	replay = hideReplayCards(replay)
+
+	if replay.Log[0].Deal != nil || replay.Log[1].Events[0].Card != (Card{}) {
+		t.Error("Expected the deal and the drawn card to be hidden")
+	}
+
+	if replay.Log[2].Events[0].Card.String() != "B2" {
+		t.Error("Expected the played card to still be shown")
+	}
+}
+
+func TestNotation(t *testing.T) {
+	rules := DefaultRuleSet()
+	rules.SevenO = true
+
+	record := &GameRecord{
+		Seed:    0,
+		Players: []string{"Nia", "Eric B"},
+		Rules:   rules,
+		Actions: []Action{Action{Kind: StartAction, Player: 0}},
+	}
+
+	// Play the first legal move each turn until the round is over
+	game := NewRecordGame(record)
+	game, _, _ = Apply(game, record.Actions[0])
+
+	for len(record.Actions) < 60 && game.State == GamePlaying {
+		player := game.ActivePlayer
+		moves := GetLegalMoves(game, player)
+		action := Action{Kind: PassAction, Player: player}
+
+		switch {
+		case len(moves.SwapTargets) > 0:
+			action = Action{Kind: SwapHandsAction, Player: player, Target: moves.SwapTargets[0]}
+		case len(moves.PlayableCards) > 0:
+			action = Action{Kind: PlayAction, Player: player, Cards: moves.PlayableCards[:1]}
+			if game.Players[player].Cards[moves.PlayableCards[0]].IsWild() {
+				action.Color = Blue
+			}
+		case moves.CanDraw:
+			action = Action{Kind: DrawAction, Player: player}
+		}
+
+		next, _, err := Apply(game, action)
+		if err != nil {
+			t.Fatal(fmt.Sprintf("Didn't expect an error: %s", err))
+		}
+
+		game = next
+		record.Actions = append(record.Actions, action)
+	}
+
+	text, err := FormatGame(record)
+	if err != nil {
+		t.Fatal(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if !strings.Contains(text, "[Player \"Eric B\"]") || !strings.Contains(text, "[sevenO \"true\"]") {
+		t.Error("Expected the players and rules to be written as tags")
+	}
+
+	if !strings.Contains(text, "\"Eric B\":") {
+		t.Error("Expected the name with a space to be quoted")
+	}
+
+	parsed, parsedRecord, err := ParseGame(text)
+	if err != nil {
+		t.Fatal(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if diff := deep.Equal(parsed, game); diff != nil {
+		t.Error(diff)
+	}
+
+	if diff := deep.Equal(parsedRecord, record); diff != nil {
+		t.Error(diff)
+	}
+
+	if _, _, err := ParseGame("[Player \"Nia\"]\n[Player \"Eric\"]\nstart Nia:wild+4/B"); err == nil {
+		t.Error("Expected an error playing a card the player doesn't hold")
+	}
+
+	if _, _, err := ParseGame("[Player \"Nia\"]\n[handSize \"lots\"]"); err == nil {
+		t.Error("Expected an error for an invalid rule")
+	}
+}
+
+func TestBots(t *testing.T) {
+	game := EmptyGame("", "", 0)
+	game = AddPlayer(game, "Nia")
+
+	if _, _, err := Apply(game, Action{Kind: StartAction, Player: 0}); err == nil {
+		t.Error("Expected an error starting a game with one player")
+	}
+
+	game, _ = AddBot(game, HardBot)
+	game, _ = AddBot(game, EasyBot)
+
+	if game.Players[1].Name != "Bot 1" || game.Players[2].Name != "Bot 2" || game.Players[1].Bot != HardBot {
+		t.Error("Expected the bots to be named after their seats")
+	}
+
+	game, _, err := Apply(game, Action{Kind: StartAction, Player: 0})
+	if err != nil {
+		t.Fatal(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if _, err := AddBot(game, NormalBot); err == nil {
+		t.Error("Expected an error adding a bot once the game has started")
+	}
+
+	game, _ = RunBots(game)
+
+	if game.State == GamePlaying && game.ActivePlayer != 0 && game.PendingChoice.Kind == NoChoice {
+		t.Error("Expected the bots to play until it's Nia's turn")
+	}
+}
+
+func TestGatherEvents(t *testing.T) {
+	game := &Game{
+		State: GamePlaying,
+		Players: []Player{
+			Player{Name: "0", Cards: cards("R5", "Y9")},
+			Player{Name: "Bot 1", Cards: cards("R6", "Y8"), Bot: NormalBot},
+			Player{Name: "Bot 2", Cards: cards("R7", "Y7"), Bot: NormalBot},
+		},
+		ActivePlayer:  0,
+		GameDirection: Clockwise,
+		DrawPile:      cards("B4", "R4", "B0"),
+		DiscardPile:   cards("R2"),
+		Rules:         DefaultRuleSet(),
+	}
+
+	applied, events, err := Apply(game, Action{Kind: PlayAction, Player: 0, Cards: []int{0}})
+	if err != nil {
+		t.Fatal(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	game, moves := RunBots(applied)
+	last := append([]Event{}, game.Events...)
+
+	game = gatherEvents(game, events, moves)
+
+	played := []int{}
+	for _, event := range game.Events {
+		if event.Kind == CardPlayedEvent {
+			played = append(played, event.Player)
+		}
+	}
+
+	if diff := deep.Equal(played, []int{0, 1, 2}); diff != nil {
+		t.Error(diff)
+	}
+
+	if diff := deep.Equal(moves[len(moves)-1].Game.Events, last); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestBotStrategy(t *testing.T) {
+	game := &Game{
+		State: GamePlaying,
+		Players: []Player{
+			Player{Name: "Bot 1", Cards: cards("B1", "B5", "Bskip", "R7", "wild"), Bot: NormalBot},
+			Player{Name: "Nia", Cards: cards("G4", "R2", "Y5")},
+		},
+		ActivePlayer:  0,
+		GameDirection: Clockwise,
+		DrawPile:      cards("B4", "R4", "B0"),
+		DiscardPile:   cards("B2"),
+		Rules:         DefaultRuleSet(),
+	}
+
+	action, ok := BotAction(game, 0)
+	if !ok || action.Kind != PlayAction || action.Cards[0] != 2 {
+		t.Error("Expected the bot to shed its skip first")
+	}
+
+	if color := botColor(game, NormalBot, game.Players[0].Cards, 4, botRandom(game)); color != Blue {
+		t.Error(fmt.Sprintf("Expected the bot to choose the color it holds most of, got %s", color))
+	}
+
+	if _, ok := BotAction(game, 1); ok {
+		t.Error("Expected the bot not to move for Nia")
+	}
+}
+
+func TestBotsOnlyGame(t *testing.T) {
+	difficulties := []BotDifficulty{EasyBot, NormalBot, HardBot}
+
+	for seed := int64(0); seed < 20; seed++ {
+		game := EmptyGame("", "", seed)
+		game.Rules.SevenO = seed%2 == 0
+		game.Rules.WildDrawFourChallenge = true
+
+		for i := 0; i < 2+int(seed%3); i++ {
+			game, _ = AddBot(game, difficulties[(int(seed)+i)%3])
+		}
+
+		game, _, err := Apply(game, Action{Kind: StartAction, Player: 0})
+		if err != nil {
+			t.Fatal(fmt.Sprintf("Didn't expect an error: %s", err))
+		}
+
+		game, moves := RunBots(game)
+
+		if game.State != RoundComplete && game.State != GameComplete {
+			t.Error(fmt.Sprintf("Expected the bots to finish the round with seed %d, made %d moves", seed, len(moves)))
+		}
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	bots := []BotDifficulty{EasyBot, NormalBot, HardBot}
+
+	result, err := Simulate(DefaultRuleSet(), bots, 20, 1)
+	if err != nil {
+		t.Fatal(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	wins := 0
+	for _, seatWins := range result.SeatWins {
+		wins += seatWins
+	}
+
+	if result.Finished != 20 || wins != 20 {
+		t.Error(fmt.Sprintf("Expected every game to be finished and won, got %d finished and %d won", result.Finished, wins))
+	}
+
+	again, _ := Simulate(DefaultRuleSet(), bots, 20, 1)
+	if diff := deep.Equal(result, again); diff != nil {
+		t.Error(diff)
+	}
+
+	rules := DefaultRuleSet()
+	rules.Teams = true
+
+	if _, err := Simulate(rules, bots, 1, 1); err == nil {
+		t.Error("Expected an error simulating a team game without four players")
+	}
+}
+
+func TestRuleVariants(t *testing.T) {
+	variants, err := ruleVariants(DefaultRuleSet(), []BotDifficulty{NormalBot, NormalBot, NormalBot})
+	if err != nil {
+		t.Fatal(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	names := map[string]RuleSet{}
+	for _, variant := range variants {
+		names[variant.name] = variant.rules
+	}
+
+	if !names["sevenO=true"].SevenO || names["drawMode=untilPlayable"].DrawMode != DrawUntilPlayable {
+		t.Error("Expected a variant switching each house rule")
+	}
+
+	if _, ok := names["handSize=7"]; ok {
+		t.Error("Didn't expect variants of number settings")
+	}
+
+	// Rules the bots wouldn't play any differently under are marked rather than simulated
+	exercised := map[string]bool{}
+	for _, variant := range variants {
+		exercised[variant.name] = variant.unexercised == ""
+	}
+
+	if exercised["multiPlay=true"] || exercised["twoPlayerReverseSkip=false"] || !exercised["sevenO=true"] {
+		t.Error("Expected only the rules the bots can show the effect of to be exercised")
+	}
+
+	if unexercised("twoPlayerReverseSkip", DefaultRuleSet(), []BotDifficulty{NormalBot, NormalBot}) != "" {
+		t.Error("Expected two player games to exercise twoPlayerReverseSkip")
+	}
+}
+
+func TestGetHint(t *testing.T) {
+	game := &Game{
+		State: GamePlaying,
+		Players: []Player{
+			Player{Name: "Nia", Cards: cards("R5", "R7", "Rskip", "B2", "wild")},
+			Player{Name: "Eric", Cards: cards("G4")},
+		},
+		ActivePlayer:  0,
+		GameDirection: Clockwise,
+		DrawPile:      cards("B4", "R4", "B0"),
+		DiscardPile:   cards("R2"),
+		Rules:         DefaultRuleSet(),
+	}
+
+	hint, err := GetHint(GetPlayersGame(game, 0))
+	if err != nil {
+		t.Fatal(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if hint.Action != PlayAction || hint.CardIndex != 2 || hint.Reason != "Shed your red skip while Eric has 1 card" {
+		t.Error(fmt.Sprintf("Expected a hint to play the skip, got %+v", hint))
+	}
+
+	// The hint only depends on what Nia can see
+	game.Players[1].Cards = cards("R9")
+	if other, _ := GetHint(GetPlayersGame(game, 0)); other != hint {
+		t.Error("Expected the hint not to depend on the other player's cards")
+	}
+
+	game.Players[1].Cards = cards("G4", "G5", "G6")
+	game.DiscardPile = cards("Y8")
+
+	hint, _ = GetHint(GetPlayersGame(game, 0))
+	if hint.CardIndex != 4 || hint.WildColor != Red {
+		t.Error(fmt.Sprintf("Expected a hint to play the wild as red, got %+v", hint))
+	}
+
+	if _, err := GetHint(GetPlayersGame(game, 1)); err == nil {
+		t.Error("Expected an error asking for a hint out of turn")
+	}
+}
+
+func TestSpectatorsGame(t *testing.T) {
+	game := startedGame()
+	game, _, _ = Apply(game, Action{Kind: DrawAction, Player: game.ActivePlayer})
+
+	view := GetPlayersGame(game, -1)
+
+	if len(view.You.Cards) != 0 || len(view.LegalMoves.PlayableCards) != 0 || view.LegalMoves.CanDraw {
+		t.Error("Expected spectators not to have a hand or any moves")
+	}
+
+	if view.OtherPlayers[0].NumCards != len(game.Players[0].Cards) || view.DiscardPileTop != game.DiscardPile[0] {
+		t.Error("Expected spectators to see the card counts and the discard pile")
+	}
+
+	if view.Events[0].Kind != CardDrawnEvent || view.Events[0].Card != (Card{}) {
+		t.Error("Expected spectators not to see the card drawn")
+	}
+
+	status := gameStatus(&PersistentSession{Spectating: true}, "ABCD", game, false)
+	if status.Abandoned || !status.Spectating || status.Game.OtherPlayers[1].Name != "Eric" {
+		t.Error("Expected spectators to be sent the game rather than an abandoned game")
+	}
+
+	status = gameStatus(&PersistentSession{PlayerName: "Ana"}, "ABCD", game, false)
+	if !status.Abandoned {
+		t.Error("Expected someone who isn't playing or watching to see the game as abandoned")
+	}
+}
+
+func TestDisconnectedPlayers(t *testing.T) {
+	game := startedGame()
+	active := game.ActivePlayer
+	name := game.Players[active].Name
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	game = SetPlayerConnected(game, name, false, now)
+	if !game.Players[active].Disconnected || !game.Players[active].DisconnectedAt.Equal(now) {
+		t.Error("Expected the player to be marked as disconnected")
+	}
+
+	if !GetPlayersGame(game, 1-active).OtherPlayers[active].Disconnected {
+		t.Error("Expected the other players to see who's disconnected")
+	}
+
+	if stillDisconnected(game, name, now) != active || stillDisconnected(game, name, now.Add(time.Second)) != -1 {
+		t.Error("Expected the player to only be disconnected from when they lost their connection")
+	}
+
+	if !waitingOn(game, active) || waitingOn(game, 1-active) {
+		t.Error("Expected the game to be waiting on the active player")
+	}
+
+	if !beingPlayed(game) || beingPlayed(EmptyGame("", "", 0)) || beingPlayed(&Game{State: GameComplete}) {
+		t.Error("Expected disconnected players to only be watched while the game is being played")
+	}
+
+	game, moves := TimeOutTurn(game, active)
+	if len(moves) == 0 || moves[0].Action.Kind != DrawAction {
+		t.Error("Expected a timed out turn to start with drawing")
+	}
+
+	if waitingOn(game, active) {
+		t.Error("Expected the turn to have passed after timing out")
+	}
+
+	game = SetPlayerConnected(game, name, true, time.Time{})
+	if game.Players[active].Disconnected || !game.Players[active].DisconnectedAt.IsZero() {
+		t.Error("Expected the player to be connected again")
+	}
+
+	rules, err := UpdateRuleSet(DefaultRuleSet(), map[string]string{"gracePeriod": "0", "afterGracePeriod": "remove"})
+	if err != nil || rules.GracePeriod != 0 || rules.AfterGracePeriod != DisconnectRemove {
+		t.Error("Expected the grace period rules to be updated")
+	}
+
+	if _, err := UpdateRuleSet(DefaultRuleSet(), map[string]string{"turnTimeout": "1"}); err == nil {
+		t.Error("Expected a turn timeout that's too short to be rejected")
+	}
+}
+
+func TestRemovePlayer(t *testing.T) {
+	game := EmptyGame("", "", 0)
+	game = AddPlayer(game, "Nia")
+	game = AddPlayer(game, "Eric")
+	game = AddPlayer(game, "Ana")
+	game = DrawHands(game)
+	game, _ = StartGame(game)
+
+	game.ActivePlayer = 2
+	drawPileCount := len(game.DrawPile)
+	cardCount := len(game.Players[0].Cards)
+
+	game = RemovePlayer(game, "Nia")
+
+	if len(game.Players) != 2 || game.Players[0].Name != "Eric" {
+		t.Error("Expected the first player to be removable")
+	}
+
+	if len(game.DrawPile) != drawPileCount+cardCount {
+		t.Error("Expected the player's cards to go back to the draw pile")
+	}
+
+	if game.Players[game.ActivePlayer].Name != "Ana" {
+		t.Error("Expected the active player to keep their turn when someone before them leaves")
+	}
+
+	game = RemovePlayer(game, "Ana")
+
+	if game.ActivePlayer != 0 || game.Players[game.ActivePlayer].Name != "Eric" {
+		t.Error("Expected the turn to pass on when the active player leaves")
+	}
+}
+
+func TestRemovePlayerDuringChoice(t *testing.T) {
+	newGame := func() *Game {
+		game := &Game{
+			State: GamePlaying,
+			Players: []Player{
+				Player{Name: "0", Cards: cards("R1", "B5")},
+				Player{Name: "1", Cards: cards("R0", "Y1")},
+				Player{Name: "2", Cards: cards("R7", "G1")},
+			},
+			ActivePlayer:  2,
+			GameDirection: Clockwise,
+			DrawPile:      cards("B4", "R4", "B0"),
+			DiscardPile:   cards("R2"),
+			Rules:         DefaultRuleSet(),
+		}
+		game.Rules.SevenO = true
+
+		game, _ = PlayCard(game, 0, "")
+
+		return game
+	}
+
+	// Someone sitting before the chooser leaves
+	game := RemovePlayer(newGame(), "0")
+
+	if !(game.PendingChoice.Kind == SwapHandsChoice && game.PendingChoice.Player == 1) {
+		t.Error("Expected the choice to move with the chooser's seat")
+	}
+
+	if _, err := DrawCard(game); err == nil {
+		t.Error("Expected the game to still be waiting on the choice")
+	}
+
+	if _, err := ChooseSwapTarget(game, 1, 0); err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	// The chooser leaves
+	game = RemovePlayer(newGame(), "2")
+
+	if game.PendingChoice.Kind != NoChoice || game.ActivePlayer != 0 {
+		t.Error("Expected the choice to be dropped and the turn to pass on")
+	}
+}
+
+func TestRemovePlayerDuringChallenge(t *testing.T) {
+	newGame := func() *Game {
+		game := &Game{
+			State: GamePlaying,
+			Players: []Player{
+				Player{Name: "0", Cards: cards("R1", "B5")},
+				Player{Name: "1", Cards: cards("R7", "G1", "wild+4")},
+				Player{Name: "2", Cards: cards("R0", "Y1")},
+				Player{Name: "3", Cards: cards("B0", "Y2")},
+			},
+			ActivePlayer:  1,
+			GameDirection: Clockwise,
+			DrawPile:      cards("B4", "R4", "B0", "B1", "Y2", "Y3"),
+			DiscardPile:   cards("R2"),
+			Rules:         DefaultRuleSet(),
+		}
+
+		game, _ = PlayCard(game, 2, "G")
+
+		return game
+	}
+
+	// Someone sitting before both sides of the wild+4 leaves
+	game := RemovePlayer(newGame(), "0")
+
+	if !(game.WildDrawFour.Player == 0 && game.WildDrawFour.Victim == 1 && canChallenge(game, 1)) {
+		t.Error("Expected the wild+4 to move with the players' seats")
+	}
+
+	game, err := ChallengeWildFour(game, 1)
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if !(game.Challenge.Guilty && game.Challenge.Challenged == 0 && len(game.Players[0].Cards) == 6) {
+		t.Error("Expected the player who played the wild+4 to draw 4 cards")
+	}
+
+	// The challenge result follows the players' seats too
+	game = RemovePlayer(game, "3")
+
+	if !(game.Challenge != nil && game.Challenge.Challenger == 1) {
+		t.Error("Expected the challenge result to be kept")
+	}
+
+	// The player who played the wild+4 leaves
+	game = RemovePlayer(newGame(), "1")
+
+	if game.WildDrawFour != nil || canChallenge(game, game.ActivePlayer) {
+		t.Error("Expected the wild+4 to no longer be challengeable")
+	}
+}
+
+func TestRemovePlayerOwingPenalty(t *testing.T) {
+
+	game := &Game{
+		State: GamePlaying,
+		Players: []Player{
+			Player{Name: "0", Cards: cards("R+2", "B5")},
+			Player{Name: "1", Cards: cards("R0", "Y1")},
+			Player{Name: "2", Cards: cards("G1", "G2")},
+		},
+		ActivePlayer:  0,
+		GameDirection: Clockwise,
+		DrawPile:      cards("B4", "R4", "B0", "B1"),
+		DiscardPile:   cards("R2"),
+		Rules:         DefaultRuleSet(),
+	}
+
+	game, err := PlayCard(game, 0, "")
+	if err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	// Player 1 is removed before drawing the +2
+	game = RemovePlayer(game, "1")
+
+	if !(game.ActivePlayer == 1 && game.Players[1].Name == "2" && game.MustDraw == 0) {
+		t.Error("Expected player 2 to take their turn without drawing the penalty")
+	}
+
+	if _, err := DrawCard(game); err != nil {
+		t.Error(fmt.Sprintf("Didn't expect an error: %s", err))
+	}
+
+	if len(game.Players[1].Cards) != 3 {
+		t.Error("Expected player 2 to draw a single card")
+	}
+}
+
+func TestHost(t *testing.T) {
+	game := EmptyGame("", "", 0)
+	game = AddPlayer(game, "Nia")
+	game.Host = "Nia"
+	game, _ = AddBot(game, NormalBot)
+	game = AddPlayer(game, "Eric")
+	game = AddPlayer(game, "Ana")
+
+	if _, err := TransferHost(game, "Bot 1"); err == nil {
+		t.Error("Expected bots not to be able to host")
+	}
+
+	if _, err := TransferHost(game, "Sam"); err == nil {
+		t.Error("Expected only players in the game to be able to host")
+	}
+
+	game = SetPlayerConnected(game, "Eric", false, time.Now())
+	game = RemovePlayer(game, "Nia")
+
+	if game.Host != "Ana" {
+		t.Errorf("Expected hosting to pass to the next connected player, got %s", game.Host)
+	}
+
+	game, err := TransferHost(game, "Eric")
+	if err != nil || game.Host != "Eric" {
+		t.Error("Expected the host to be able to hand over hosting")
+	}
+
+	game = RemovePlayer(game, "Ana")
+	game = RemovePlayer(game, "Eric")
+
+	if game.Host != "" {
+		t.Error("Expected a game with only bots left not to have a host")
+	}
+
+	// Hosting passes to a disconnected player if nobody else is connected, and a player coming back to
+	// a game without a host takes it over
+	game = AddPlayer(game, "Sam")
+	game = AddPlayer(game, "Lee")
+	game.Host = "Sam"
+	game = SetPlayerConnected(game, "Lee", false, time.Now())
+	game = RemovePlayer(game, "Sam")
+
+	if game.Host != "Lee" {
+		t.Errorf("Expected hosting to pass to a disconnected player, got %s", game.Host)
+	}
+
+	game.Host = ""
+	game = SetPlayerConnected(game, "Bot 1", true, time.Time{})
+	game = SetPlayerConnected(game, "Lee", true, time.Time{})
+
+	if game.Host != "Lee" {
+		t.Errorf("Expected the reconnecting player to take over hosting, got %s", game.Host)
+	}
+
+	if !isHost(&PersistentSession{PlayerName: "Nia"}, &Game{Host: "Nia"}) || isHost(&PersistentSession{Spectating: true}, &Game{}) {
+		t.Error("Expected only the host's session to be treated as the host")
+	}
+}
+
+func TestBanPlayer(t *testing.T) {
+	game := startedGame()
+	game.Players[1].SessionId = "abc123"
+
+	if GetPlayersGame(game, 1).You.SessionId != "" {
+		t.Error("Expected session ids to stay on the server")
+	}
+
+	game = BanPlayer(game, "Eric")
+	game = BanPlayer(game, "Eric")
+
+	if !IsBanned(game, "abc123") || len(game.BannedSessions) != 1 {
+		t.Error("Expected the player's session to be banned once")
+	}
+
+	if IsBanned(game, "") || GetPlayerIndex(game, "Eric") == -1 {
+		t.Error("Expected banning to leave removing the player to the caller")
+	}
+
+	seed := int64(7)
+	replay := &Replay{
+		Seed: &seed,
+		Log: []ReplayEntry{
+			ReplayEntry{Verb: "createGame", Player: "Nia"},
+			ReplayEntry{Verb: "joinGame", Player: "Eric"},
+			ReplayEntry{Verb: "joinGame", Player: "Ana"},
+			ReplayEntry{Verb: "banPlayer", Player: "Nia", Data: map[string]string{"playerName": "Eric"}},
+		},
+	}
+
+	record, err := RecordFromReplay(replay)
+	if err != nil || len(record.Players) != 2 || record.Players[1] != "Ana" {
+		t.Error("Expected players who were kicked to be left out of the record")
+	}
+}
+
 func TestAdvancePlayerSkip(t *testing.T) {
 
-	game := Game{
+	game := &Game{
 		State: GameCreated,
 		Players: []Player{
-			Player{Name: "0", Cards: []string{"Rskip"}},
-			Player{Name: "1", Cards: []string{"Rskip"}},
-			Player{Name: "2", Cards: []string{"Rskip"}},
+			Player{Name: "0", Cards: cards("Rskip")},
+			Player{Name: "1", Cards: cards("Rskip")},
+			Player{Name: "2", Cards: cards("Rskip")},
 		},
 		ActivePlayer:  0,
 		MustDraw:      0,
 		GameDirection: Clockwise,
-		DrawPile:      []string{"B4", "R4", "B0", "G+2"},
-		DiscardPile:   []string{"R0"},
+		DrawPile:      cards("B4", "R4", "B0", "G+2"),
+		DiscardPile:   cards("R0"),
 	}
 
 	// Play a Skip
@@ -297,7 +1831,7 @@ func TestAdvancePlayerSkip(t *testing.T) {
 	}
 
 	game, err = DrawCard(game)
-	game = DoneDrawing(game)
+	game, err = DoneDrawing(game)
 
 	if !(game.ActivePlayer == 0 && game.MustDraw == 0) {
 		t.Error("Expected player 0 to be active")
@@ -306,18 +1840,18 @@ func TestAdvancePlayerSkip(t *testing.T) {
 
 func TestAdvancePlayerReverse(t *testing.T) {
 
-	game := Game{
+	game := &Game{
 		State: GameCreated,
 		Players: []Player{
-			Player{Name: "0", Cards: []string{"Rrev"}},
-			Player{Name: "1", Cards: []string{"Rrev"}},
-			Player{Name: "2", Cards: []string{"Rrev"}},
+			Player{Name: "0", Cards: cards("Rrev")},
+			Player{Name: "1", Cards: cards("Rrev")},
+			Player{Name: "2", Cards: cards("Rrev")},
 		},
 		ActivePlayer:  0,
 		MustDraw:      0,
 		GameDirection: Clockwise,
-		DrawPile:      []string{"B4", "R4", "B0", "G+2"},
-		DiscardPile:   []string{"R0"},
+		DrawPile:      cards("B4", "R4", "B0", "G+2"),
+		DiscardPile:   cards("R0"),
 	}
 
 	// Play a reverse
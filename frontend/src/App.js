@@ -5,6 +5,7 @@ import { useClientState } from "./hooks";
 
 import Game from "./pages/Game";
 import Lobby from "./pages/Lobby";
+import RoundSummary from "./pages/RoundSummary";
 import Summary from "./pages/Summary";
 import Welcome from "./pages/Welcome";
 
@@ -55,6 +56,7 @@ const Consumer = ({}) => {
       {status === 0 && <Lobby {...commonProps} />}
       {status === 1 && <Game {...commonProps} />}
       {status === 2 && <Summary {...commonProps} />}
+      {status === 4 && <RoundSummary {...commonProps} />}
     </Container>
   );
 };
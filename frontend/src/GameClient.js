@@ -186,6 +186,7 @@ class GameClient {
       this.state.gamePneumonic = response.d.gamePneumonic;
       this.state.gameState = response.d.game;
       this.state.isHost = response.d.isHost;
+      this.state.spectating = response.d.spectating;
     }
 
     this._flushStateChange();
@@ -250,6 +251,16 @@ class GameClient {
     return this._processGameUpdate(response);
   }
 
+  async spectateGame(gameId) {
+    this.state.playerName = "";
+
+    const response = await this._enqueueCommand("spectateGame", {
+      gameId: gameId.trim().toUpperCase(),
+    });
+
+    return this._processGameUpdate(response);
+  }
+
   async leaveGame() {
     if (!this.state.gameState) {
       console.warn("Not playing a game");
@@ -269,6 +280,31 @@ class GameClient {
     return this._processGameUpdate(response);
   }
 
+  async nextRound() {
+    const response = await this._enqueueCommand("nextRound");
+    return this._processGameUpdate(response);
+  }
+
+  async addBot(difficulty) {
+    const response = await this._enqueueCommand("addBot", { difficulty });
+    return this._processGameUpdate(response);
+  }
+
+  async kickPlayer(playerName) {
+    const response = await this._enqueueCommand("kickPlayer", { playerName });
+    return this._processGameUpdate(response);
+  }
+
+  async banPlayer(playerName) {
+    const response = await this._enqueueCommand("banPlayer", { playerName });
+    return this._processGameUpdate(response);
+  }
+
+  async transferHost(playerName) {
+    const response = await this._enqueueCommand("transferHost", { playerName });
+    return this._processGameUpdate(response);
+  }
+
   async endGame() {
     const response = await this._enqueueCommand("endGame");
     return this._processGameUpdate(response);
@@ -292,6 +328,11 @@ class GameClient {
     return this._processGameUpdate(response);
   }
 
+  async getHint() {
+    const response = await this._enqueueCommand("getHint");
+    return response.d;
+  }
+
   getGameState() {
     return this.state.gameState;
   }
@@ -43,10 +43,17 @@ const Name = styled.div`
   color: ${(props) => (props.playerActive ? colors.accent : colors.darkGray)};
 `;
 
-export default ({ name, numCards, playerActive }) => {
+const Status = styled.div`
+  font-size: 1em;
+  margin-bottom: 0.25em;
+  color: ${colors.darkGray};
+`;
+
+export default ({ name, numCards, playerActive, disconnected }) => {
   return (
     <Container playerActive={playerActive} animate={numCards === 1}>
       <Name playerActive={playerActive}>{name}</Name>
+      {disconnected && <Status>Reconnecting...</Status>}
 
       <CardSet numCards={numCards} />
     </Container>
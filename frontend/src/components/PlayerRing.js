@@ -68,7 +68,7 @@ const nextPlayer = (index, dir, count) => {
 export default ({ activePlayer, direction, players, discardPile }) => {
   return (
     <>
-      {players.map(({ name, numCards }, index) => (
+      {players.map(({ name, numCards, disconnected }, index) => (
         <PlayerContainer
           key={name}
           left={getPlayerLeftPos(index, players.length)}
@@ -78,6 +78,7 @@ export default ({ activePlayer, direction, players, discardPile }) => {
             playerActive={activePlayer === index}
             name={name}
             numCards={numCards}
+            disconnected={disconnected}
           />
         </PlayerContainer>
       ))}
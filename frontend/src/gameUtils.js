@@ -1,35 +1,10 @@
 import { colors } from "./constants";
 
-export const isValidPlay = (discardPileTop, wildColor, card) => {
-  const topColor = getCardColorKey(discardPileTop, wildColor);
-  const topNumber = getCardNumber(discardPileTop);
-
-  if (card.startsWith("wild")) {
-    return true;
-  }
-
-  if (
-    (discardPileTop.endsWith("+2") && card.endsWith("+2")) ||
-    (discardPileTop.endsWith("rev") && card.endsWith("rev")) ||
-    (discardPileTop.endsWith("skip") && card.endsWith("skip"))
-  ) {
-    return true;
-  }
-
-  if (getCardColorKey(card, "") === topColor) {
-    return true;
-  }
-
-  if (topNumber != -1 && getCardNumber(card) === topNumber) {
-    return true;
-  }
-
-  return false;
-};
-
-export const nextValidCard = (discardPileTop, wildColor, cards, index, dir) => {
+// Returns the next playable card index after the given index in the given direction, using the
+// playable cards the server sends in legalMoves
+export const nextPlayableCard = (playableCards, cards, index, dir) => {
   for (let i = index + dir; i < cards.length && i >= 0; i += dir) {
-    if (isValidPlay(discardPileTop, wildColor, cards[i])) {
+    if (playableCards.includes(i)) {
       return i;
     }
   }
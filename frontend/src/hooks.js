@@ -26,11 +26,18 @@ export const useActions = () => {
   return {
     createGame: (name) => client.createGame(name),
     joinGame: (name, gameCode) => client.joinGame(name, gameCode),
+    spectateGame: (gameCode) => client.spectateGame(gameCode),
     leaveGame: () => client.leaveGame(),
     startGame: () => client.startGame(),
+    nextRound: () => client.nextRound(),
+    addBot: (difficulty) => client.addBot(difficulty),
+    kickPlayer: (playerName) => client.kickPlayer(playerName),
+    banPlayer: (playerName) => client.banPlayer(playerName),
+    transferHost: (playerName) => client.transferHost(playerName),
     endGame: () => client.endGame(),
     playCard: (cardIndex, wildColor) => client.playCard(cardIndex, wildColor),
     drawCard: () => client.drawCard(),
     doneDrawing: () => client.doneDrawing(),
+    getHint: () => client.getHint(),
   };
 };
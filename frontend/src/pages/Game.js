@@ -4,7 +4,7 @@ import Deck from "../components/Deck";
 import Hand from "../components/Hand";
 import Gameboard from "../components/Gameboard";
 import { useEffect, useRef, useState } from "react";
-import { isValidPlay, nextValidCard } from "../gameUtils";
+import { nextPlayableCard } from "../gameUtils";
 import { useActions } from "../hooks";
 import ColorPicker from "../components/ColorPicker";
 
@@ -41,6 +41,27 @@ const Button = styled.div`
   }
 `;
 
+const HintContainer = styled.div`
+  position: absolute;
+  right: 5%;
+  top: 10%;
+  max-width: 20%;
+`;
+
+const PassContainer = styled.div`
+  position: absolute;
+  left: 5%;
+  top: 10%;
+`;
+
+const HintReason = styled.div`
+  margin-top: 0.5em;
+  padding: 0.5em;
+
+  background-color: ${colors.lightGray};
+  border-radius: 0.25em;
+`;
+
 const DeckContainer = styled.div`
   position: absolute;
   left: 2%;
@@ -77,7 +98,7 @@ const GameboardContainer = styled.div`
   width: 85vh;
 `;
 
-const useCardSelection = (you, yourTurn, wildColor, discardPileTop, mustDraw) => {
+const useCardSelection = (you, playableCards, discardPileTop, mustDraw) => {
   const [selectedCard, setSelectedCard] = useState(0);
 
   const trySelectingCard = (index) => {
@@ -94,26 +115,20 @@ const useCardSelection = (you, yourTurn, wildColor, discardPileTop, mustDraw) =>
       return;
     }
 
-    if (isValidPlay(discardPileTop, wildColor, you.cards[index])) {
+    if (playableCards.includes(index)) {
       setSelectedCard(index);
     }
   };
 
   useEffect(() => {
-    const firstValidCard = nextValidCard(
-      discardPileTop,
-      wildColor,
-      you.cards,
-      -1,
-      1
-    );
+    const firstValidCard = nextPlayableCard(playableCards, you.cards, -1, 1);
 
     if (firstValidCard === null || mustDraw > 0) {
       setSelectedCard(-1);
     } else {
       setSelectedCard(firstValidCard);
     }
-  }, [discardPileTop, you.cards]);
+  }, [discardPileTop, you.cards, playableCards]);
 
   return [selectedCard, trySelectingCard];
 };
@@ -160,12 +175,23 @@ export default ({
     otherPlayers,
     you,
     wildColor,
+    legalMoves,
+    rules,
   },
   isHost,
 }) => {
   const containerRef = useRef();
 
-  const { playCard, drawCard, endGame, leaveGame } = useActions();
+  const {
+    playCard,
+    drawCard,
+    doneDrawing,
+    endGame,
+    leaveGame,
+    getHint,
+  } = useActions();
+
+  const [hint, setHint] = useState(null);
 
   const yourTurn = otherPlayers[activePlayer].name === you.name;
 
@@ -176,21 +202,46 @@ export default ({
     selectColor,
   ] = useColorPicker();
 
+  const { playableCards } = legalMoves;
+
   const [selectedCard, trySelectingCard] = useCardSelection(
     you,
-    yourTurn,
-    wildColor,
+    playableCards,
     discardPileTop,
     mustDraw
   );
 
-  const noValidCards =
-    nextValidCard(discardPileTop, wildColor, you.cards, -1, 1) === null;
+  const noValidCards = playableCards.length === 0;
+
+  // Hints are only good for the turn they were asked for
+  useEffect(() => setHint(null), [activePlayer, discardPileTop, you.cards]);
 
+  const showHint = async () => {
+    try {
+      const suggestion = await getHint();
+      setHint(suggestion.reason);
+
+      if (suggestion.action === "play") {
+        trySelectingCard(parseInt(suggestion.cardIndex, 10));
+      }
+
+      if (suggestion.wildColor) {
+        selectColor(suggestion.wildColor);
+      }
+    } catch (message) {
+      setHint(message);
+    }
+  };
+
+  // Once a player has drawn as many cards as the rules allow, the deck passes the turn instead
   const tryDrawCard = () => {
     if (!yourTurn) return;
 
-    drawCard();
+    if (legalMoves.canDraw) {
+      drawCard();
+    } else if (legalMoves.canDoneDrawing) {
+      doneDrawing();
+    }
   };
 
   const tryPlayCard = (index) => {
@@ -200,7 +251,7 @@ export default ({
       playCard(selectedCard, selectedColor);
       pickColor(false);
     } else if (selectedCard < 0) {
-      drawCard();
+      tryDrawCard();
     } else {
       if (you.cards[selectedCard].includes("wild")) {
         pickColor(true);
@@ -225,7 +276,7 @@ export default ({
         selectColor(nextColor(selectedColor, 1));
       } else {
         trySelectingCard(
-          nextValidCard(discardPileTop, wildColor, you.cards, selectedCard, 1)
+          nextPlayableCard(playableCards, you.cards, selectedCard, 1)
         );
       }
     }
@@ -235,7 +286,7 @@ export default ({
         selectColor(nextColor(selectedColor, -1));
       } else {
         trySelectingCard(
-          nextValidCard(discardPileTop, wildColor, you.cards, selectedCard, -1)
+          nextPlayableCard(playableCards, you.cards, selectedCard, -1)
         );
       }
     }
@@ -250,9 +301,22 @@ export default ({
     <Container onKeyPress={handleKeyPress} ref={containerRef}>
       <ButtonContainer>
         {isHost && <Button onClick={() => endGame()}>End Game</Button>}
-        {!isHost && <Button onClick={() => leaveGame()}>Leave Game</Button>}
+        <Button onClick={() => leaveGame()}>Leave Game</Button>
       </ButtonContainer>
 
+      {yourTurn && legalMoves.canDoneDrawing && (
+        <PassContainer>
+          <Button onClick={() => doneDrawing()}>Pass</Button>
+        </PassContainer>
+      )}
+
+      {rules.allowHints && yourTurn && (
+        <HintContainer>
+          <Button onClick={() => showHint()}>Hint</Button>
+          {hint && <HintReason>{hint}</HintReason>}
+        </HintContainer>
+      )}
+
       <DeckContainer>
         <Deck
           cardCount={drawPileCount}
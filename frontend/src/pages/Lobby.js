@@ -100,10 +100,34 @@ const Pneumonic = styled.div`
   font-size: 1.3em;
 `;
 
-const Player = ({ name }) => <PlayerContainer>{name}</PlayerContainer>;
+const PlayerAction = styled.span`
+  margin-left: 0.5em;
+  font-size: 0.8em;
+  color: ${colors.accent};
+  cursor: pointer;
+`;
+
+const Player = ({ name, bot, host, onMakeHost, onKick, onBan }) => (
+  <PlayerContainer>
+    {name}
+    {bot && ` (${bot})`}
+    {host && " (host)"}
+    {onMakeHost && <PlayerAction onClick={onMakeHost}>Make Host</PlayerAction>}
+    {onKick && <PlayerAction onClick={onKick}>Kick</PlayerAction>}
+    {onBan && <PlayerAction onClick={onBan}>Ban</PlayerAction>}
+  </PlayerContainer>
+);
 
 export default ({ gameCode, gamePneumonic, game, isHost }) => {
-  const { startGame, endGame, leaveGame } = useActions();
+  const {
+    startGame,
+    addBot,
+    kickPlayer,
+    banPlayer,
+    transferHost,
+    endGame,
+    leaveGame,
+  } = useActions();
 
   return (
     <Container>
@@ -115,16 +139,36 @@ export default ({ gameCode, gamePneumonic, game, isHost }) => {
         <Pneumonic>{gamePneumonic}</Pneumonic>
 
         <h3>Players:</h3>
-        {game.otherPlayers.map(({ name }) => (
-          <Player name={name} key={name} />
+        {game.otherPlayers.map(({ name, bot }) => (
+          <Player
+            name={name}
+            bot={bot}
+            host={name === game.host}
+            onMakeHost={
+              isHost && !bot && name !== game.host
+                ? () => transferHost(name)
+                : undefined
+            }
+            onKick={
+              isHost && name !== game.host ? () => kickPlayer(name) : undefined
+            }
+            onBan={
+              isHost && !bot && name !== game.host
+                ? () => banPlayer(name)
+                : undefined
+            }
+            key={name}
+          />
         ))}
 
+        {isHost && <Button onClick={() => addBot("normal")}>Add Bot</Button>}
+
         {isHost && game.otherPlayers.length > 1 && (
           <PositiveButton onClick={() => startGame()}>Start Game!</PositiveButton>
         )}
 
         {isHost && <NegativeButton onClick={() => endGame()}>Cancel Game</NegativeButton>}
-        {!isHost && <NegativeButton onClick={() => leaveGame()}>Leave Game</NegativeButton>}
+        <NegativeButton onClick={() => leaveGame()}>Leave Game</NegativeButton>
 
       </Frame>
     </Container>
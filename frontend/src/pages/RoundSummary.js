@@ -0,0 +1,114 @@
+import styled from "styled-components";
+import { colors } from "../constants";
+
+import { useActions } from "../hooks";
+
+const Container = styled.div`
+  width: 100%;
+  height: 100%;
+
+  font-size: 1.25em;
+
+  display: flex;
+  justify-content: center;
+  align-items: center;
+`;
+
+const Frame = styled.div`
+  width: 35vw;
+  max-width: 400px;
+  min-width: 300px;
+
+  background-color: ${colors.lightGray};
+  text-align: center;
+  padding: 1em;
+  border-radius: 0.5em;
+`;
+
+const Button = styled.div`
+  cursor: pointer;
+  padding: 0.5em;
+  border-radius: 0.5em;
+
+  transition: 0.3s background-color;
+  background-color: ${colors.accent};
+  color: ${colors.white};
+
+  :hover {
+    background-color: ${colors.accentDark};
+    transition: 0.3s background-color;
+  }
+`;
+
+const PlayButton = styled(Button)`
+  background-color: ${colors.green};
+  margin-bottom: 0.25em;
+`;
+
+const ScoreTable = styled.table`
+  width: 100%;
+  margin-bottom: 0.5em;
+  border-collapse: collapse;
+
+  th,
+  td {
+    padding: 0.25em;
+    border-bottom: 0.5px solid ${colors.accentDark};
+  }
+`;
+
+// The points the player won in the round that just finished
+const lastRoundScore = ({ roundScores }) =>
+  roundScores && roundScores.length > 0
+    ? roundScores[roundScores.length - 1]
+    : 0;
+
+export default ({ game, isHost }) => {
+  const { nextRound, endGame, leaveGame } = useActions();
+
+  const { round, roundWinner, otherPlayers, rules } = game;
+  const winner = otherPlayers[roundWinner];
+
+  return (
+    <Container>
+      <Frame>
+        <h2>
+          Round {round}: {winner ? `${winner.name} won!` : "Round over"}
+        </h2>
+
+        <ScoreTable>
+          <thead>
+            <tr>
+              <th>Player</th>
+              <th>Round</th>
+              <th>Total</th>
+            </tr>
+          </thead>
+          <tbody>
+            {otherPlayers.map((player) => (
+              <tr key={player.name}>
+                <td>
+                  {player.name}
+                  {player.eliminated && " (out)"}
+                </td>
+                <td>{lastRoundScore(player)}</td>
+                <td>{player.score}</td>
+              </tr>
+            ))}
+          </tbody>
+        </ScoreTable>
+
+        {rules.targetScore > 0 && <p>First to {rules.targetScore} wins</p>}
+
+        {isHost ? (
+          <PlayButton onClick={() => nextRound()}>Next Round</PlayButton>
+        ) : (
+          <p>Waiting for the host to start the next round</p>
+        )}
+
+        {isHost && <Button onClick={() => endGame()}>End Game</Button>}
+        <Button onClick={() => leaveGame()}>Leave Game</Button>
+      </Frame>
+    </Container>
+  );
+};
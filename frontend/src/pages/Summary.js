@@ -106,7 +106,7 @@ export default ({ gameCode, game, isHost }) => {
         )}
 
         {isHost && <Button onClick={() => endGame()}>End Game</Button>}
-        {!isHost && <Button onClick={() => leaveGame()}>Leave Game</Button>}
+        <Button onClick={() => leaveGame()}>Leave Game</Button>
       </Frame>
     </Container>
   );
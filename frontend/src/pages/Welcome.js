@@ -96,7 +96,7 @@ const JoinGameForm = () => {
   const [gameCode, setGameCode] = useState("");
   const [error, setError] = useState(null);
 
-  const { joinGame } = useActions();
+  const { joinGame, spectateGame } = useActions();
 
   const doJoin = async () => {
     if (playerName.length === 0) {
@@ -116,6 +116,20 @@ const JoinGameForm = () => {
     }
   };
 
+  // Watching a game doesn't need a name, only the game code
+  const doSpectate = async () => {
+    if (gameCode.length !== 4) {
+      setError("Invalid game code");
+      return;
+    }
+
+    try {
+      await spectateGame(gameCode);
+    } catch (e) {
+      setError(e);
+    }
+  };
+
   const onKeyPress = (e) => {
     if (e.key === "Enter") {
       doJoin();
@@ -138,6 +152,7 @@ const JoinGameForm = () => {
         onKeyPress={onKeyPress}
       />
       <Button onClick={doJoin}>Join Game</Button>
+      <Button onClick={doSpectate}>Watch Game</Button>
       <ErrorText>{error}</ErrorText>
     </FormContainer>
   );